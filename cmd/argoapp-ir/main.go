@@ -0,0 +1,56 @@
+// Command argoapp-ir reads argoapp.ApplicationConfigs in JSON or YAML from
+// stdin, in the format of argoapp.LoadApplicationConfigs, and writes the
+// ir.Document of the generated Applications as JSON to stdout. Unknown keys
+// are rejected. With -schema it writes the JSON Schema of the document
+// instead. It is meant to be called by infrastructure as code tools in other
+// languages, e.g. Pulumi or cdk8s programs.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/giantswarm/argoapp/pkg/argoapp"
+	"github.com/giantswarm/argoapp/pkg/ir"
+)
+
+func main() {
+	schema := flag.Bool("schema", false, "write the JSON Schema of the output and exit")
+	flag.Parse()
+
+	err := run(os.Stdin, os.Stdout, *schema)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "argoapp-ir: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(in io.Reader, out io.Writer, schema bool) error {
+	if schema {
+		_, err := out.Write(ir.Schema())
+		return err
+	}
+
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+
+	configs, err := argoapp.DecodeApplicationConfigs(data)
+	if err != nil {
+		return err
+	}
+
+	doc, err := ir.New(configs)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(doc)
+}
@@ -6,4 +6,5 @@ require (
 	github.com/giantswarm/microerror v0.3.0
 	github.com/gogo/protobuf v1.3.2 // indirect
 	k8s.io/apimachinery v0.18.9
+	sigs.k8s.io/yaml v1.2.0
 )
@@ -0,0 +1,225 @@
+// Package alerting generates Prometheus Operator PrometheusRule objects
+// alerting on the state of the Applications generated by package argoapp, so
+// monitoring of the fleet is generated together with it.
+package alerting
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/giantswarm/argoapp/pkg/argoapp"
+)
+
+// PrometheusRuleResource is the Prometheus Operator PrometheusRule resource.
+var PrometheusRuleResource = schema.GroupVersionResource{
+	Group:    "monitoring.coreos.com",
+	Version:  "v1",
+	Resource: "prometheusrules",
+}
+
+const (
+	// AlertOutOfSync fires for Applications out of sync for OutOfSyncFor.
+	AlertOutOfSync = "ArgoAppOutOfSync"
+	// AlertDegraded fires for Applications degraded for DegradedFor.
+	AlertDegraded = "ArgoAppDegraded"
+	// AlertSyncFailed fires for Applications whose sync failed after all
+	// the retries were exhausted.
+	AlertSyncFailed = "ArgoAppSyncFailed"
+)
+
+const (
+	defaultOutOfSyncFor = time.Hour
+	defaultDegradedFor  = 15 * time.Minute
+	defaultSeverity     = "page"
+)
+
+// labelNameRegexp matches valid Prometheus label names.
+var labelNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// managedByMetricLabel is the argocd_app_labels label of the ManagedByLabel.
+const managedByMetricLabel = "label_giantswarm_io_managed_by"
+
+type RuleConfig struct {
+	// Name of the PrometheusRule.
+	Name string
+	// Namespace of the PrometheusRule.
+	Namespace string
+	// Installation is the name of the installation the Argo CD instance
+	// runs in. It is set as the installation label of the alerts.
+	Installation string
+
+	// ArgoNamespace is the namespace of the Applications the alerts are
+	// scoped to. Defaults to all namespaces.
+	ArgoNamespace string
+	// ManagedOnly scopes the alerts to the Applications with the
+	// argoapp.ManagedByLabel. It requires the Argo CD application controller
+	// to export the label with
+	// --metrics-application-labels=giantswarm.io/managed-by.
+	ManagedOnly bool
+
+	// OutOfSyncFor defaults to 1 hour.
+	OutOfSyncFor time.Duration
+	// DegradedFor defaults to 15 minutes.
+	DegradedFor time.Duration
+	// Severity is the severity label of the alerts. Defaults to "page".
+	Severity string
+	// Labels are additional labels of the alerts, e.g. the owning team.
+	Labels map[string]string
+}
+
+// NewPrometheusRule returns the PrometheusRule with the AlertOutOfSync,
+// AlertDegraded and AlertSyncFailed alerts of the installation. The alerts
+// are based on the metrics exported by the Argo CD application controller.
+func NewPrometheusRule(config RuleConfig) (*unstructured.Unstructured, error) {
+	if config.Name == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Name must not be empty", config)
+	}
+	if config.Namespace == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Namespace must not be empty", config)
+	}
+	if config.Installation == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Installation must not be empty", config)
+	}
+	if config.OutOfSyncFor < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.OutOfSyncFor must not be negative", config)
+	}
+	if config.OutOfSyncFor == 0 {
+		config.OutOfSyncFor = defaultOutOfSyncFor
+	}
+	if config.DegradedFor < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.DegradedFor must not be negative", config)
+	}
+	if config.DegradedFor == 0 {
+		config.DegradedFor = defaultDegradedFor
+	}
+	if config.Severity == "" {
+		config.Severity = defaultSeverity
+	}
+	for k := range config.Labels {
+		if !labelNameRegexp.MatchString(k) {
+			return nil, microerror.Maskf(invalidConfigError, "%T.Labels key %#q is not a valid Prometheus label name", config, k)
+		}
+	}
+
+	labels := map[string]interface{}{
+		"installation": config.Installation,
+		"severity":     config.Severity,
+	}
+	for k, v := range config.Labels {
+		labels[k] = v
+	}
+
+	rules := []interface{}{
+		newAlert(
+			AlertOutOfSync,
+			config.scope(config.selector("argocd_app_info", `sync_status="OutOfSync"`)),
+			config.OutOfSyncFor,
+			labels,
+			fmt.Sprintf("Application {{ $labels.namespace }}/{{ $labels.name }} in installation %s is out of sync for more than %s.", config.Installation, promDuration(config.OutOfSyncFor)),
+		),
+		newAlert(
+			AlertDegraded,
+			config.scope(config.selector("argocd_app_info", `health_status="Degraded"`)),
+			config.DegradedFor,
+			labels,
+			fmt.Sprintf("Application {{ $labels.namespace }}/{{ $labels.name }} in installation %s is degraded for more than %s.", config.Installation, promDuration(config.DegradedFor)),
+		),
+		newAlert(
+			AlertSyncFailed,
+			config.scope(fmt.Sprintf("increase(%s[15m]) > 0", config.selector("argocd_app_sync_total", `phase=~"Error|Failed"`))),
+			0,
+			labels,
+			fmt.Sprintf("Sync of Application {{ $labels.namespace }}/{{ $labels.name }} in installation %s failed after all retries.", config.Installation),
+		),
+	}
+
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": PrometheusRuleResource.GroupVersion().String(),
+			"kind":       "PrometheusRule",
+			"metadata": map[string]interface{}{
+				"name":      config.Name,
+				"namespace": config.Namespace,
+				"labels": map[string]interface{}{
+					argoapp.ManagedByLabel: argoapp.ManagedByLabelValue,
+				},
+			},
+			"spec": map[string]interface{}{
+				"groups": []interface{}{
+					map[string]interface{}{
+						"name":  "argoapp",
+						"rules": rules,
+					},
+				},
+			},
+		},
+	}
+
+	return obj, nil
+}
+
+// selector returns the metric selector scoped to the ArgoNamespace.
+func (c RuleConfig) selector(metric string, matcher string) string {
+	matchers := []string{matcher}
+	if c.ArgoNamespace != "" {
+		matchers = append(matchers, fmt.Sprintf("namespace=%q", c.ArgoNamespace))
+	}
+
+	return fmt.Sprintf("%s{%s}", metric, strings.Join(matchers, ","))
+}
+
+// scope scopes the expression to the managed Applications with ManagedOnly.
+func (c RuleConfig) scope(expr string) string {
+	if !c.ManagedOnly {
+		return expr
+	}
+
+	return fmt.Sprintf("(%s) * on(name, namespace) group_left() argocd_app_labels{%s=%q}", expr, managedByMetricLabel, argoapp.ManagedByLabelValue)
+}
+
+func newAlert(name, expr string, d time.Duration, labels map[string]interface{}, description string) interface{} {
+	l := map[string]interface{}{}
+	for k, v := range labels {
+		l[k] = v
+	}
+
+	alert := map[string]interface{}{
+		"alert":  name,
+		"expr":   expr,
+		"labels": l,
+		"annotations": map[string]interface{}{
+			"description": description,
+		},
+	}
+	if d > 0 {
+		alert["for"] = promDuration(d)
+	}
+
+	return alert
+}
+
+// promDuration formats the duration in the Prometheus duration format, e.g.
+// "1h" or "90s".
+func promDuration(d time.Duration) string {
+	units := []struct {
+		unit time.Duration
+		name string
+	}{
+		{time.Hour, "h"},
+		{time.Minute, "m"},
+		{time.Second, "s"},
+	}
+	for _, u := range units {
+		if d%u.unit == 0 {
+			return fmt.Sprintf("%d%s", d/u.unit, u.name)
+		}
+	}
+
+	return fmt.Sprintf("%dms", d/time.Millisecond)
+}
@@ -0,0 +1,293 @@
+// Package apprequest reconciles AppRequest custom resources, self-service
+// requests of a team to deploy an app, into Argo CD Applications. The
+// AppRequest CRD is defined in config/crd.
+package apprequest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/giantswarm/microerror"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/giantswarm/argoapp/pkg/argoapp"
+	"github.com/giantswarm/argoapp/pkg/argoappclient"
+	"github.com/giantswarm/argoapp/pkg/tenancy"
+)
+
+// AppRequestAnnotation is set on the Applications materialized from an
+// AppRequest to the AppRequest "<namespace>/<name>".
+const AppRequestAnnotation = "argoapp.giantswarm.io/app-request"
+
+const (
+	// PhaseReady means the Application is materialized.
+	PhaseReady = "Ready"
+	// PhaseRejected means the AppRequest violates the tenant policy or
+	// exceeds the tenant quotas.
+	PhaseRejected = "Rejected"
+	// PhaseFailed means the Application could not be generated from the
+	// AppRequest.
+	PhaseFailed = "Failed"
+)
+
+// Resource is the AppRequest resource.
+var Resource = schema.GroupVersionResource{
+	Group:    "argoapp.giantswarm.io",
+	Version:  "v1alpha1",
+	Resource: "apprequests",
+}
+
+// StatusResourceInterface is ResourceInterface able to update the status
+// subresource. It is satisfied by k8s.io/client-go/dynamic.ResourceInterface.
+type StatusResourceInterface interface {
+	argoappclient.ResourceInterface
+	UpdateStatus(ctx context.Context, obj *unstructured.Unstructured, options metav1.UpdateOptions) (*unstructured.Unstructured, error)
+}
+
+// Spec is the AppRequest spec.
+type Spec struct {
+	App       string `json:"app"`
+	Version   string `json:"version"`
+	Catalog   string `json:"catalog"`
+	Namespace string `json:"namespace"`
+	// Team is optional. It must match the team owning the AppRequest
+	// namespace, see ReconcilerConfig.Team.
+	Team string `json:"team,omitempty"`
+}
+
+// Status is the AppRequest status.
+type Status struct {
+	Phase              string `json:"phase,omitempty"`
+	Message            string `json:"message,omitempty"`
+	Application        string `json:"application,omitempty"`
+	ObservedGeneration int64  `json:"observedGeneration,omitempty"`
+}
+
+type ReconcilerConfig struct {
+	// AppRequests is scoped to the namespace the AppRequests are
+	// reconciled in.
+	AppRequests  StatusResourceInterface
+	Applications argoappclient.ResourceInterface
+	Policy       tenancy.Policy
+
+	// Namespace is the namespace AppRequests is scoped to.
+	Namespace string
+	// Team is the tenant the AppRequests in Namespace are validated against
+	// and whose tenancy.TenantLabel is set on their Applications. Defaults
+	// to Namespace. It is never taken from the AppRequest, so requesters
+	// can not claim the policy and quotas of another team.
+	Team string
+
+	// ConfigRef is the config repository revision of the materialized
+	// Applications.
+	ConfigRef string
+}
+
+// Reconciler materializes AppRequests allowed by the tenant policy into
+// Applications with the same name and reports the outcome in the AppRequest
+// status. It does not watch the resources itself, Reconcile is meant to be
+// called by a controller, e.g. built with controller-runtime, for every
+// AppRequest change.
+type Reconciler struct {
+	appRequests  StatusResourceInterface
+	applications argoappclient.ResourceInterface
+	policy       tenancy.Policy
+	namespace    string
+	team         string
+	configRef    string
+}
+
+func NewReconciler(config ReconcilerConfig) (*Reconciler, error) {
+	if config.AppRequests == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.AppRequests must not be empty", config)
+	}
+	if config.Applications == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Applications must not be empty", config)
+	}
+	if config.Namespace == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Namespace must not be empty", config)
+	}
+	if config.Team == "" {
+		config.Team = config.Namespace
+	}
+	if config.ConfigRef == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ConfigRef must not be empty", config)
+	}
+
+	r := &Reconciler{
+		appRequests:  config.AppRequests,
+		applications: config.Applications,
+		policy:       config.Policy,
+		namespace:    config.Namespace,
+		team:         config.Team,
+		configRef:    config.ConfigRef,
+	}
+
+	return r, nil
+}
+
+// Reconcile reconciles the AppRequest with the given name. The Application of
+// a deleted AppRequest is deleted. Rejected and failed AppRequests are not
+// retried until they change, so no error is returned for them. An Application
+// already materialized is left untouched when its AppRequest is changed to
+// violate the tenant policy.
+func (r *Reconciler) Reconcile(ctx context.Context, name string) error {
+	obj, err := r.appRequests.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return microerror.Mask(r.deleteApplication(ctx, name))
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if obj.GetDeletionTimestamp() != nil {
+		return microerror.Mask(r.deleteApplication(ctx, name))
+	}
+
+	var spec Spec
+	{
+		m, _, err := unstructured.NestedMap(obj.Object, "spec")
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		err = runtime.DefaultUnstructuredConverter.FromUnstructured(m, &spec)
+		if err != nil {
+			return microerror.Mask(r.updateStatus(ctx, obj, Status{Phase: PhaseFailed, Message: err.Error()}))
+		}
+	}
+
+	if spec.Team != "" && spec.Team != r.team {
+		message := fmt.Sprintf("team %#q does not own namespace %#q", spec.Team, r.namespace)
+		return microerror.Mask(r.updateStatus(ctx, obj, Status{Phase: PhaseRejected, Message: message}))
+	}
+
+	config := argoapp.ApplicationConfig{
+		Name:                    obj.GetName(),
+		AppName:                 spec.App,
+		AppVersion:              spec.Version,
+		AppCatalog:              spec.Catalog,
+		AppDestinationNamespace: spec.Namespace,
+		ConfigRef:               r.configRef,
+	}
+
+	err = r.policy.Validate(r.team, config)
+	if tenancy.IsPolicyViolation(err) {
+		return microerror.Mask(r.updateStatus(ctx, obj, Status{Phase: PhaseRejected, Message: err.Error()}))
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	{
+		list, err := r.applications.List(ctx, metav1.ListOptions{LabelSelector: tenancy.TenantLabel})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		err = r.policy.CheckQuota(r.team, config, list.Items)
+		if tenancy.IsQuotaExceeded(err) {
+			return microerror.Mask(r.updateStatus(ctx, obj, Status{Phase: PhaseRejected, Message: err.Error()}))
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	app, err := argoapp.NewApplication(config)
+	if argoapp.IsInvalidConfig(err) {
+		return microerror.Mask(r.updateStatus(ctx, obj, Status{Phase: PhaseFailed, Message: err.Error()}))
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	existing, err := r.applications.Get(ctx, app.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		// Fall through.
+	} else if err != nil {
+		return microerror.Mask(err)
+	} else if existing.GetAnnotations()[AppRequestAnnotation] != r.owner(name) {
+		message := fmt.Sprintf("Application %#q already exists and is not owned by this AppRequest", app.GetName())
+		return microerror.Mask(r.updateStatus(ctx, obj, Status{Phase: PhaseFailed, Message: message}))
+	}
+
+	annotations := app.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[AppRequestAnnotation] = r.owner(name)
+	app.SetAnnotations(annotations)
+	labels := app.GetLabels()
+	labels[tenancy.TenantLabel] = r.team
+	app.SetLabels(labels)
+
+	err = argoapp.SealIntegrity(app)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	_, err = argoappclient.ApplyApplication(ctx, r.applications, app)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return microerror.Mask(r.updateStatus(ctx, obj, Status{Phase: PhaseReady, Application: app.GetName()}))
+}
+
+// deleteApplication deletes the Application materialized from the AppRequest
+// with the given name. Protected Applications are not deleted, see
+// argoappclient.DeleteApplication.
+func (r *Reconciler) deleteApplication(ctx context.Context, name string) error {
+	app, err := r.applications.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if app.GetAnnotations()[AppRequestAnnotation] != r.owner(name) {
+		return nil
+	}
+
+	err = argoappclient.DeleteApplication(ctx, r.applications, name, argoappclient.DeleteOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// owner returns the AppRequestAnnotation value of the AppRequest with the
+// given name.
+func (r *Reconciler) owner(name string) string {
+	return r.namespace + "/" + name
+}
+
+// updateStatus updates the AppRequest status unless it is up to date, so
+// status updates do not trigger needless reconciliations.
+func (r *Reconciler) updateStatus(ctx context.Context, obj *unstructured.Unstructured, status Status) error {
+	status.ObservedGeneration = obj.GetGeneration()
+
+	m, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&status)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	current, _, err := unstructured.NestedMap(obj.Object, "status")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if reflect.DeepEqual(current, m) {
+		return nil
+	}
+
+	obj = obj.DeepCopy()
+	obj.Object["status"] = m
+
+	_, err = r.appRequests.UpdateStatus(ctx, obj, metav1.UpdateOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package argoapp
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// anonymizedPrefix prefixes the hashed values.
+const anonymizedPrefix = "anon-"
+
+// preservedKeys are the keys whose string values do not identify the
+// customer, e.g. enums and sync settings, and are kept by Anonymize.
+var preservedKeys = map[string]bool{
+	"apiVersion":         true,
+	"kind":               true,
+	"group":              true,
+	"version":            true,
+	"status":             true,
+	"phase":              true,
+	"type":               true,
+	"targetRevision":     true,
+	"revision":           true,
+	"syncOptions":        true,
+	"finalizers":         true,
+	"duration":           true,
+	"maxDuration":        true,
+	"jsonPointers":       true,
+	"jqPathExpressions":  true,
+	"creationTimestamp":  true,
+	"deployedAt":         true,
+	"startedAt":          true,
+	"finishedAt":         true,
+	"reconciledAt":       true,
+	"lastTransitionTime": true,
+}
+
+// droppedKeys are removed by Anonymize. They are not useful in bug reports.
+var droppedKeys = map[string]bool{
+	"managedFields":   true,
+	"uid":             true,
+	"resourceVersion": true,
+	"selfLink":        true,
+}
+
+// Anonymize returns a copy of the Application, e.g. to attach to an upstream
+// bug report, with the customer identifying string values (names,
+// namespaces, URLs, plugin env and Helm values, messages) replaced with
+// hashes. Equal values are replaced with equal hashes so the references
+// between fields are preserved. The hashes are salted per call so they can
+// not be reversed by hashing guessed values. Label and annotation keys, plugin
+// env names and values of the enums and sync settings are kept, and the
+// structure of the object is preserved.
+func Anonymize(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	salt := make([]byte, 16)
+	_, _ = rand.Read(salt)
+
+	a := anonymizer{salt: salt}
+
+	return &unstructured.Unstructured{
+		Object: a.anonymizeMap(obj.DeepCopy().Object, ""),
+	}
+}
+
+type anonymizer struct {
+	salt []byte
+}
+
+func (a anonymizer) anonymizeMap(m map[string]interface{}, parentKey string) map[string]interface{} {
+	for k, v := range m {
+		if droppedKeys[k] {
+			delete(m, k)
+			continue
+		}
+		// Plugin env names, e.g. KONFIGURE_APP_NAME, describe the
+		// structure.
+		if parentKey == "env" && k == "name" {
+			continue
+		}
+		m[k] = a.anonymizeValue(v, k)
+	}
+
+	return m
+}
+
+func (a anonymizer) anonymizeValue(v interface{}, key string) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return a.anonymizeMap(v, key)
+	case []interface{}:
+		for i := range v {
+			v[i] = a.anonymizeValue(v[i], key)
+		}
+		return v
+	case string:
+		if preservedKeys[key] || v == "" {
+			return v
+		}
+		return a.hash(v)
+	default:
+		return v
+	}
+}
+
+func (a anonymizer) hash(v string) string {
+	h := sha256.New()
+	_, _ = h.Write(a.salt)
+	_, _ = h.Write([]byte(v))
+
+	return anonymizedPrefix + hex.EncodeToString(h.Sum(nil))[:12]
+}
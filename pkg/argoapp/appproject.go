@@ -0,0 +1,126 @@
+package argoapp
+
+import (
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+type AppProjectConfig struct {
+	// Name of the Argo CD AppProject CR, e.g. "collections".
+	Name string
+	// ArgoNamespace is the namespace where Argo CD is installed and the
+	// AppProject CR is created. Defaults to Defaults.ArgoNamespace.
+	ArgoNamespace string
+	// Description of the project.
+	Description string
+
+	// SourceRepos are the repository URLs Applications in the project can
+	// be sourced from. Defaults to Defaults.ConfigRepoURL.
+	SourceRepos []string
+	// Destinations are the clusters and namespaces Applications in the
+	// project can deploy to.
+	Destinations []AppProjectDestination
+	// ClusterResourceWhitelist are the cluster scoped resources
+	// Applications in the project can manage. Use Group and Kind "*" to
+	// allow all of them.
+	ClusterResourceWhitelist []metav1.GroupKind
+}
+
+type AppProjectDestination struct {
+	// Server is the API server URL of the cluster. Only one of Server and
+	// Name can be set.
+	Server string
+	// Name is the name of the cluster as registered in Argo CD. Only one of
+	// Server and Name can be set.
+	Name string
+	// Namespace is the namespace glob pattern, e.g. "*".
+	Namespace string
+}
+
+func NewAppProject(config AppProjectConfig) (*unstructured.Unstructured, error) {
+	if config.Name == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Name must not be empty", config)
+	}
+	if config.ArgoNamespace != "" {
+		if errs := validation.IsDNS1123Label(config.ArgoNamespace); len(errs) > 0 {
+			return nil, microerror.Maskf(invalidConfigError, "%T.ArgoNamespace %#q is invalid: %s", config, config.ArgoNamespace, strings.Join(errs, ", "))
+		}
+	}
+	for i, d := range config.Destinations {
+		if d.Server != "" && d.Name != "" {
+			return nil, microerror.Maskf(invalidConfigError, "only one of %T.Destinations[%d].Server and %T.Destinations[%d].Name can be set", config, i, config, i)
+		}
+		if d.Namespace == "" {
+			return nil, microerror.Maskf(invalidConfigError, "%T.Destinations[%d].Namespace must not be empty", config, i)
+		}
+	}
+
+	defaults := GetDefaults()
+	if config.ArgoNamespace == "" {
+		config.ArgoNamespace = defaults.ArgoNamespace
+	}
+	if len(config.SourceRepos) == 0 {
+		config.SourceRepos = []string{defaults.ConfigRepoURL}
+	}
+
+	var sourceRepos []interface{}
+	for _, r := range config.SourceRepos {
+		sourceRepos = append(sourceRepos, r)
+	}
+
+	var destinations []interface{}
+	for _, d := range config.Destinations {
+		destination := map[string]interface{}{
+			"namespace": d.Namespace,
+		}
+		if d.Server != "" {
+			destination["server"] = d.Server
+		}
+		if d.Name != "" {
+			destination["name"] = d.Name
+		}
+		destinations = append(destinations, destination)
+	}
+
+	var clusterResourceWhitelist []interface{}
+	for _, gk := range config.ClusterResourceWhitelist {
+		clusterResourceWhitelist = append(clusterResourceWhitelist, map[string]interface{}{
+			"group": gk.Group,
+			"kind":  gk.Kind,
+		})
+	}
+
+	spec := map[string]interface{}{
+		"sourceRepos":              sourceRepos,
+		"destinations":             destinations,
+		"clusterResourceWhitelist": clusterResourceWhitelist,
+	}
+	if config.Description != "" {
+		spec["description"] = config.Description
+	}
+
+	// See the argo-cd source for detailed object structure:
+	// https://github.com/argoproj/argo-cd/blob/master/pkg/apis/application/v1alpha1/app_project_types.go
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": argoAPIVersion,
+			"kind":       argoAppProjectKind,
+			"metadata": map[string]interface{}{
+				"name":      config.Name,
+				"namespace": config.ArgoNamespace,
+				"labels": map[string]interface{}{
+					ManagedByLabel: ManagedByLabelValue,
+				},
+			},
+			"spec": spec,
+		},
+	}
+
+	RemoveEmptyFields(obj)
+
+	return obj, nil
+}
@@ -1,7 +1,8 @@
 package argoapp
 
 import (
-	"github.com/giantswarm/microerror"
+	"time"
+
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
@@ -12,108 +13,173 @@ const (
 	argoNamespace       = "argocd"
 	argoAPIVersion      = "argoproj.io/v1alpha1"
 	argoApplicationKind = "Application"
+	argoAppProjectKind  = "AppProject"
 
 	argoProjectName = "collections"
 
 	argoResourceFinalizer = "resources-finalizer.argocd.argoproj.io"
 
-	configRepoURL = "https://github.com/giantswarm/config.git"
+	configRepoURL       = "https://github.com/giantswarm/config.git"
+	konfigurePluginName = "konfigure"
+
+	pluginEnvAppName    = "KONFIGURE_APP_NAME"
+	pluginEnvAppVersion = "KONFIGURE_APP_VERSION"
+	pluginEnvAppCatalog = "KONFIGURE_APP_CATALOG"
+	// pluginEnvAppDisableForceUpgrade asks konfigure to set the
+	// chart-operator.giantswarm.io/force-helm-upgrade: "false" annotation on
+	// the generated App CR. The Argo CD Application annotations are not
+	// propagated to the App CR, so the plugin env is the only way to pass
+	// it. It takes effect only with a konfigure release reading the
+	// variable, older releases ignore it and keep force upgrades enabled.
+	pluginEnvAppDisableForceUpgrade = "KONFIGURE_APP_DISABLE_FORCE_UPGRADE"
+
+	inClusterServer = "https://kubernetes.default.svc"
 )
 
+const (
+	// ManagedByLabel is set to ManagedByLabelValue on all the objects
+	// generated by this package.
+	ManagedByLabel      = "giantswarm.io/managed-by"
+	ManagedByLabelValue = "argoapp"
+)
+
+// ManifestGeneratePathsAnnotation lists the paths, separated with ";", which
+// affect the Application manifests. Relative paths are relative to the
+// Application source path. Argo CD and argoappclient.WebhookRelay only
+// refresh the Application when files in these paths change.
+const ManifestGeneratePathsAnnotation = "argocd.argoproj.io/manifest-generate-paths"
+
+// ApplicationConfig is the config of a generated Application. The default,
+// validate and since struct tags of the fields are returned by
+// ConfigReference and must be kept in sync with the validation.
 type ApplicationConfig struct {
-	// Name of the Argo CD Application CR to be created in the argocd
-	// namespace.
-	Name string
+	// Name of the Argo CD Application CR to be created in the Argo CD
+	// namespace (see ArgoNamespace). It must be no more than MaxNameLength
+	// characters, see GenerateName.
+	Name string `validate:"required, DNS-1123 subdomain, at most 63 characters" since:"0.1.0"`
+
+	// ArgoNamespace is the namespace where Argo CD is installed and the
+	// Application CR is created. Defaults to Defaults.ArgoNamespace.
+	ArgoNamespace string `default:"Defaults.ArgoNamespace" validate:"DNS-1123 label" since:"0.2.0"`
+	// ArgoProject is the Argo CD project the Application belongs to.
+	// Defaults to Defaults.Project.
+	ArgoProject string `default:"Defaults.Project" since:"0.2.0"`
 
 	// AppName as defined in the App Catalog.
-	AppName string
+	AppName string `validate:"required" since:"0.1.0"`
 	// AppVersion as defined in the App Catalog.
-	AppVersion string
+	AppVersion string `validate:"required, semantic version" since:"0.1.0"`
 	// AppCatalog name.
-	AppCatalog string
+	AppCatalog string `validate:"required" since:"0.1.0"`
 	// AppDestinationNamespace is the namespace where the application's
-	// manifests are created.
-	AppDestinationNamespace string
+	// manifests are created. It must be empty with MultiNamespace.
+	AppDestinationNamespace string `validate:"required unless MultiNamespace, empty with MultiNamespace, DNS-1123 label" since:"0.1.0"`
+	// MultiNamespace leaves the Application destination namespace empty so
+	// the namespaces set in the application's manifests are not overridden
+	// by Argo CD. Namespaced manifests without a namespace are created in
+	// the Argo CD namespace.
+	MultiNamespace bool `default:"false" since:"0.2.0"`
+	// AppNamespaceCreation makes Argo CD create the destination namespace
+	// (CreateNamespace=true sync option) when it does not exist.
+	AppNamespaceCreation bool `default:"false" since:"0.2.0"`
+	// AppDestinationServer is the API server URL of the cluster where the
+	// application's manifests are created. The cluster must be registered
+	// in Argo CD. Defaults to the cluster Argo CD runs in. Only one of
+	// AppDestinationServer and AppDestinationName can be set.
+	AppDestinationServer string `default:"https://kubernetes.default.svc" validate:"exclusive with AppDestinationName" since:"0.2.0"`
+	// AppDestinationName is the name of the cluster, as registered in Argo
+	// CD, where the application's manifests are created. Only one of
+	// AppDestinationServer and AppDestinationName can be set.
+	AppDestinationName string `validate:"exclusive with AppDestinationServer" since:"0.2.0"`
 
 	// ConfigRef is the valid git ref of giantswarm/config repository used
 	// to configure the application. Usually the desired value is the major
 	// tag, e.g.: v1, v2, etc.
-	ConfigRef string
+	ConfigRef string `validate:"required, git ref" since:"0.1.0"`
+	// SourcePath is the directory of the giantswarm/config repository the
+	// konfigure plugin is run in, e.g. "apps/hello-world". It must be
+	// relative to the repository root and clean. Defaults to ".". When set,
+	// it is also declared in the ManifestGeneratePathsAnnotation.
+	SourcePath string `default:"." validate:"clean relative path" since:"0.2.0"`
+	// ExtraPluginEnv are additional environment variables passed to the
+	// konfigure plugin, e.g. KONFIGURE_INSTALLATION. They must not override
+	// the variables set from AppName, AppVersion, AppCatalog and
+	// DisableForceUpgrade.
+	ExtraPluginEnv map[string]string `validate:"environment variable names, must not set the KONFIGURE_APP_* variables" since:"0.2.0"`
+	// ConfigHash is the hash of the konfigure inputs computed with
+	// ComputeConfigHash. It is set as the ConfigHashAnnotation.
+	ConfigHash string `since:"0.2.0"`
 	// DisableForceUpgrade sets appropriate annotation to prevent helm
-	// force upgrades.
-	DisableForceUpgrade bool
+	// force upgrades. It is passed to konfigure which annotates the
+	// generated App CR.
+	DisableForceUpgrade bool `default:"false" since:"0.1.0"`
+	// DisableCascadeDelete leaves the application's resources in the
+	// cluster when the Application is deleted. By default the Argo CD
+	// resources finalizer is set so they are deleted with it. See
+	// SetCascadedDeletion.
+	DisableCascadeDelete bool `default:"false" since:"0.2.0"`
+
+	// Retry configures retrying of failed syncs. It overrides the retry
+	// strategy of the SyncPolicyPreset.
+	Retry *RetryStrategy `validate:"valid backoff durations, non-negative backoff factor" since:"0.2.0"`
+
+	// SyncOptions are Argo CD sync options in the Key=value format, e.g.
+	// "ServerSideApply=true" or "PrunePropagationPolicy=background".
+	SyncOptions []string `validate:"known Argo CD sync options in the Key=value format" since:"0.2.0"`
+
+	// OwnershipLabels are set on the destination namespace, e.g. team or
+	// cost center labels used for billing attribution. Setting them makes
+	// Argo CD create and manage the destination namespace
+	// (CreateNamespace=true sync option).
+	OwnershipLabels map[string]string `validate:"label keys and values, not allowed with MultiNamespace" since:"0.2.0"`
+
+	// SyncWave is set as the SyncWaveAnnotation to order the sync of the
+	// Applications of a collection managed by a parent Application, e.g.
+	// CRDs first, operators second and workloads last. The annotation is
+	// not set for the default wave 0.
+	SyncWave int `default:"0" since:"0.2.0"`
+	// HookType is optional. It is set as the HookAnnotation, e.g.
+	// HookTypePreSync.
+	HookType HookType `validate:"known hook type" since:"0.2.0"`
+
+	// Notifications subscribe channels, e.g. Slack channels, to the Argo
+	// CD notifications of the Application.
+	Notifications []NotificationSubscription `validate:"required trigger and service without dots, required channel without semicolons" since:"0.2.0"`
+
+	// RevisionHistoryLimit is optional. It limits the number of deployed
+	// revisions kept in the Application history. Argo CD keeps 10 by
+	// default.
+	RevisionHistoryLimit *int64 `default:"10" validate:"non-negative" since:"0.2.0"`
+	// Info are user-facing entries shown in the Argo CD UI, e.g. the
+	// owner, docs URL or runbook link.
+	Info []InfoEntry `validate:"unique non-empty names, non-empty values" since:"0.2.0"`
+
+	// TTL is optional. When set, the Application expires when the TTL
+	// elapsed since its creation and can be deleted with
+	// argoappclient.Reap. Regenerating the Application does not extend its
+	// lifetime. See TTLAnnotation.
+	TTL time.Duration `default:"0" validate:"non-negative" since:"0.2.0"`
+	// Protected sets the ProtectedAnnotation, so the Application is never
+	// deleted by the argoappclient helpers unless they are forced.
+	Protected bool `default:"false" since:"0.2.0"`
+
+	// SyncPolicyPreset is the name of the sync policy preset to use, e.g.
+	// PresetProduction, PresetStaging, PresetManual or a preset registered
+	// with RegisterSyncPolicyPreset. When empty the Application is synced
+	// automatically with pruning and self healing enabled.
+	SyncPolicyPreset string `validate:"registered preset" since:"0.2.0"`
 }
 
+// NewApplication generates an Argo CD Application CR for the given config
+// using the package level Defaults. See SetDefaults and
+// NewApplicationWithOptions.
 func NewApplication(config ApplicationConfig) (*unstructured.Unstructured, error) {
-	if config.Name == "" {
-		return nil, microerror.Maskf(invalidConfigError, "%T.Name must not be empty", config)
-	}
-	if config.AppName == "" {
-		return nil, microerror.Maskf(invalidConfigError, "%T.AppName must not be empty", config)
-	}
-	if config.AppVersion == "" {
-		return nil, microerror.Maskf(invalidConfigError, "%T.AppVersion must not be empty", config)
-	}
-	if config.AppCatalog == "" {
-		return nil, microerror.Maskf(invalidConfigError, "%T.AppCatalog must not be empty", config)
-	}
-	if config.AppDestinationNamespace == "" {
-		return nil, microerror.Maskf(invalidConfigError, "%T.AppDestinationNamespace must not be empty", config)
-	}
-	if config.ConfigRef == "" {
-		return nil, microerror.Maskf(invalidConfigError, "%T.ConfigRef must not be empty", config)
-	}
-
-	// See the argo-cd source for detailed object structure:
-	// https://github.com/argoproj/argo-cd/blob/master/pkg/apis/application/v1alpha1/types.go
-	obj := map[string]interface{}{
-		"apiVersion": argoAPIVersion,
-		"kind":       argoApplicationKind,
-		"metadata": map[string]interface{}{
-			"name":      config.Name,
-			"namespace": argoNamespace,
-			"finalizers": []string{
-				argoResourceFinalizer,
-			},
-		},
-		"spec": map[string]interface{}{
-			"project": argoProjectName,
-			"source": map[string]interface{}{
-				"repoURL":        configRepoURL,
-				"targetRevision": config.ConfigRef,
-				"path":           ".",
-				"plugin": map[string]interface{}{
-					"name": "konfigure",
-					"env": []map[string]interface{}{
-						{
-							"name":  "KONFIGURE_APP_NAME",
-							"value": config.AppName,
-						},
-						{
-							"name":  "KONFIGURE_APP_VERSION",
-							"value": config.AppVersion,
-						},
-						{
-							"name":  "KONFIGURE_APP_CATALOG",
-							"value": config.AppCatalog,
-						},
-					},
-				},
-			},
-			"destination": map[string]interface{}{
-				"namespace": config.AppDestinationNamespace,
-				"server":    "https://kubernetes.default.svc",
-			},
-			"syncPolicy": map[string]interface{}{
-				"automated": map[string]interface{}{
-					"prune": true,
-					// If set to true allows deleting all application resources during automatic syncing (false by default).
-					"allowEmpty": false,
-					"selfHeal":   true,
-				},
-			},
-		},
-	}
-
-	return &unstructured.Unstructured{Object: obj}, nil
+	return NewApplicationWithOptions(config)
+}
+
+// NewApplicationWithDefaults generates an Argo CD Application CR for the given
+// config using the given Defaults. Empty fields of d are taken from the package
+// level Defaults.
+func NewApplicationWithDefaults(config ApplicationConfig, d Defaults) (*unstructured.Unstructured, error) {
+	return newGenerator(d.merge(GetDefaults())).NewApplication(config)
 }
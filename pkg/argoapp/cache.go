@@ -0,0 +1,71 @@
+package argoapp
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// maxTemplateCacheSize bounds the number of cached Application templates.
+// The cache is dropped when it is full, which only happens with a large
+// number of distinct Defaults.
+const maxTemplateCacheSize = 64
+
+var (
+	cacheMutex sync.Mutex
+
+	// templateCache holds the Application templates by the Defaults they
+	// were built for. The templates are shared by all Generators and must
+	// not be modified.
+	templateCache map[Defaults]*unstructured.Unstructured
+	// configReference is built by ConfigReference on first use.
+	configReference []FieldReference
+)
+
+// ResetCaches drops the package level caches, so they are rebuilt on next
+// use. The caches are safe to use across tests, it only exists to make tests
+// measuring allocations or relying on a cold start deterministic.
+func ResetCaches() {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	templateCache = nil
+	configReference = nil
+}
+
+// getTemplate returns the cached Application template for the Defaults. It
+// builds it on first use.
+func getTemplate(d Defaults) *unstructured.Unstructured {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	if t, ok := templateCache[d]; ok {
+		return t
+	}
+
+	if templateCache == nil || len(templateCache) >= maxTemplateCacheSize {
+		templateCache = map[Defaults]*unstructured.Unstructured{}
+	}
+	t := newApplicationTemplate(d)
+	templateCache[d] = t
+
+	return t
+}
+
+// getConfigReference returns a copy of the cached ConfigReference result.
+func getConfigReference() []FieldReference {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	if configReference == nil {
+		configReference = newConfigReference()
+	}
+
+	refs := make([]FieldReference, len(configReference))
+	for i, ref := range configReference {
+		ref.Validation = append([]string(nil), ref.Validation...)
+		refs[i] = ref
+	}
+
+	return refs
+}
@@ -0,0 +1,77 @@
+package argoapp
+
+import (
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// CollectionDefaults are the values shared by the apps of a collection. They
+// are set on the ApplicationConfigs which leave the fields empty.
+type CollectionDefaults struct {
+	AppCatalog string
+	ConfigRef  string
+}
+
+// NewApplicationCollection generates the Application CRs of a collection in
+// one call. The problems of all the configs, including Application names
+// which are not unique, are reported in a single error matched by
+// IsInvalidConfig and is a *ValidationError.
+func NewApplicationCollection(configs []ApplicationConfig) ([]*unstructured.Unstructured, error) {
+	apps, err := NewApplicationCollectionWithDefaults(configs, CollectionDefaults{})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return apps, nil
+}
+
+// NewApplicationCollectionWithDefaults is like NewApplicationCollection but
+// sets the CollectionDefaults on the configs first.
+func NewApplicationCollectionWithDefaults(configs []ApplicationConfig, d CollectionDefaults) ([]*unstructured.Unstructured, error) {
+	withDefaults, problems := withCollectionDefaults(configs, d)
+	if len(problems) > 0 {
+		return nil, microerror.Mask(&ValidationError{Problems: problems})
+	}
+
+	var apps []*unstructured.Unstructured
+	for _, c := range withDefaults {
+		app, err := NewApplication(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		apps = append(apps, app)
+	}
+
+	return apps, nil
+}
+
+// withCollectionDefaults returns copies of the configs with the
+// CollectionDefaults set and the problems of the configs.
+func withCollectionDefaults(configs []ApplicationConfig, d CollectionDefaults) ([]ApplicationConfig, []error) {
+	var problems []error
+
+	names := map[string]int{}
+	withDefaults := make([]ApplicationConfig, len(configs))
+	for i, c := range configs {
+		if c.AppCatalog == "" {
+			c.AppCatalog = d.AppCatalog
+		}
+		if c.ConfigRef == "" {
+			c.ConfigRef = d.ConfigRef
+		}
+		withDefaults[i] = c
+
+		if j, ok := names[c.Name]; ok && c.Name != "" {
+			problems = append(problems, newProblem(ErrInvalidName, "configs[%d].Name %#q is already used by configs[%d]", i, c.Name, j))
+		} else {
+			names[c.Name] = i
+		}
+		for _, p := range c.problems() {
+			problems = append(problems, fmt.Errorf("configs[%d]: %w", i, p))
+		}
+	}
+
+	return withDefaults, problems
+}
@@ -0,0 +1,84 @@
+package argoapp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ConfigHashAnnotation holds the hash of the konfigure inputs the Application
+// was generated from. See ComputeConfigHash.
+const ConfigHashAnnotation = "argoapp.giantswarm.io/config-hash"
+
+// ComputeConfigHash returns a content hash of the konfigure inputs of the app:
+// all the files in the apps/<appName> directories of the config repository
+// checked out at configRepo, and the app catalog values. Files of other apps
+// do not affect the hash, so comparing it with the ConfigHashAnnotation of
+// the Application tells precisely whether the app configuration changed.
+func ComputeConfigHash(configRepo fs.FS, appName string, catalogValues []byte) (string, error) {
+	if appName == "" {
+		return "", microerror.Maskf(invalidConfigError, "app name must not be empty")
+	}
+
+	var files []string
+	err := fs.WalkDir(configRepo, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return fs.SkipDir
+		}
+		if !d.IsDir() && inAppDir(p, appName) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+	sort.Strings(files)
+
+	h := sha256.New()
+	for _, f := range files {
+		data, err := fs.ReadFile(configRepo, f)
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+		// Paths and contents are NUL separated so moving bytes between
+		// them changes the hash.
+		h.Write([]byte(f))
+		h.Write([]byte{0})
+		h.Write(data)
+		h.Write([]byte{0})
+	}
+	h.Write(catalogValues)
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ConfigChanged returns true when the Application was generated from
+// konfigure inputs other than the ones hashed to hash. It returns false for
+// Applications without the ConfigHashAnnotation.
+func ConfigChanged(obj *unstructured.Unstructured, hash string) bool {
+	v, ok := obj.GetAnnotations()[ConfigHashAnnotation]
+	return ok && v != hash
+}
+
+// inAppDir returns true when the file path p is in an apps/<appName>
+// directory.
+func inAppDir(p, appName string) bool {
+	segments := strings.Split(path.Dir(p), "/")
+	for i := 0; i+1 < len(segments); i++ {
+		if segments[i] == "apps" && segments[i+1] == appName {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,98 @@
+package argoapp
+
+import "sync"
+
+var (
+	defaultsMutex sync.RWMutex
+
+	defaults = Defaults{
+		ArgoNamespace: argoNamespace,
+		Project:       argoProjectName,
+		ConfigRepoURL: configRepoURL,
+		PluginName:    konfigurePluginName,
+		PluginMode:    PluginModeLegacy,
+	}
+
+	defaultGenerator = newGenerator(defaults)
+)
+
+// Defaults holds the values specific to the Argo CD instance the Application
+// CRs are generated for.
+type Defaults struct {
+	// ArgoNamespace is the namespace where Argo CD is installed. Application
+	// CRs are created in this namespace.
+	ArgoNamespace string
+	// Project is the Argo CD project the generated Applications belong to.
+	Project string
+	// ConfigRepoURL is the URL of the config repository rendered by the
+	// config management plugin.
+	ConfigRepoURL string
+	// PluginName is the name of the config management plugin registered in
+	// Argo CD. It is not used with PluginModeSidecar.
+	PluginName string
+	// PluginMode is how the config management plugin is referenced.
+	// Defaults to PluginModeLegacy.
+	PluginMode PluginMode
+}
+
+// PluginMode is how the generated Applications reference the config
+// management plugin.
+type PluginMode string
+
+const (
+	// PluginModeLegacy references the plugin registered in the argocd-cm
+	// ConfigMap by PluginName.
+	PluginModeLegacy PluginMode = "legacy"
+	// PluginModeSidecar sets only the plugin env, so the sidecar plugin
+	// (Argo CD 2.5+) is selected by its discovery rules. Argo CD passes the
+	// env to sidecar plugins prefixed with ARGOCD_ENV_, e.g.
+	// ARGOCD_ENV_KONFIGURE_APP_NAME.
+	PluginModeSidecar PluginMode = "sidecar"
+)
+
+// GetDefaults returns the package level Defaults used by NewApplication.
+func GetDefaults() Defaults {
+	defaultsMutex.RLock()
+	defer defaultsMutex.RUnlock()
+
+	return defaults
+}
+
+// SetDefaults overrides the package level Defaults used by NewApplication.
+// Empty fields are left unchanged. It is meant to be called once at program
+// startup but it is safe for concurrent use.
+func SetDefaults(d Defaults) {
+	defaultsMutex.Lock()
+	defer defaultsMutex.Unlock()
+
+	defaults = d.merge(defaults)
+	defaultGenerator = newGenerator(defaults)
+}
+
+func getDefaultGenerator() *Generator {
+	defaultsMutex.RLock()
+	defer defaultsMutex.RUnlock()
+
+	return defaultGenerator
+}
+
+// merge returns a copy of d with empty fields set to the values from base.
+func (d Defaults) merge(base Defaults) Defaults {
+	if d.ArgoNamespace == "" {
+		d.ArgoNamespace = base.ArgoNamespace
+	}
+	if d.Project == "" {
+		d.Project = base.Project
+	}
+	if d.ConfigRepoURL == "" {
+		d.ConfigRepoURL = base.ConfigRepoURL
+	}
+	if d.PluginName == "" {
+		d.PluginName = base.PluginName
+	}
+	if d.PluginMode == "" {
+		d.PluginMode = base.PluginMode
+	}
+
+	return d
+}
@@ -0,0 +1,110 @@
+package argoapp
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// FieldDiff is a managed field which differs between the live Application
+// and the desired ApplicationConfig. Field is the ApplicationConfig field
+// name, e.g. "AppVersion".
+type FieldDiff struct {
+	Field   string
+	Live    string
+	Desired string
+}
+
+// Diff lists the differing managed fields in a stable order.
+type Diff []FieldDiff
+
+// ConfigDiff compares the fields of the live Application generated from
+// ApplicationConfig (the konfigure plugin env, config ref, source path and
+// destination) with the desired config. It returns true when any of them
+// differ and an update is needed. Each differing ExtraPluginEnv variable is a
+// FieldDiff with a Field like "ExtraPluginEnv[NAME]". The ConfigRef of an Application pinned with
+// PinConfigRef is the one it was pinned from. Other fields, e.g. added by
+// users or the Application status, are not compared.
+func ConfigDiff(live *unstructured.Unstructured, desired ApplicationConfig) (Diff, bool, error) {
+	if live.GetKind() != argoApplicationKind {
+		return nil, false, microerror.Maskf(invalidConfigError, "object %#q is a %#q, not an Application", live.GetName(), live.GetKind())
+	}
+
+	env := pluginEnv(live)
+	appName, appVersion, appCatalog := env[pluginEnvAppName], env[pluginEnvAppVersion], env[pluginEnvAppCatalog]
+	disableForceUpgrade := env[pluginEnvAppDisableForceUpgrade] == "true"
+
+	configRef, _, _ := unstructured.NestedString(live.Object, "spec", "source", "targetRevision")
+	if IsPinned(live) {
+		configRef = live.GetAnnotations()[PinnedFromAnnotation]
+	}
+	sourcePath, _, _ := unstructured.NestedString(live.Object, "spec", "source", "path")
+	namespace, _, _ := unstructured.NestedString(live.Object, "spec", "destination", "namespace")
+	server, _, _ := unstructured.NestedString(live.Object, "spec", "destination", "server")
+	name, _, _ := unstructured.NestedString(live.Object, "spec", "destination", "name")
+
+	desiredSourcePath := desired.SourcePath
+	if desiredSourcePath == "" {
+		desiredSourcePath = "."
+	}
+	desiredServer := desired.AppDestinationServer
+	if desiredServer == "" && desired.AppDestinationName == "" {
+		desiredServer = inClusterServer
+	}
+
+	var diff Diff
+	for _, f := range []FieldDiff{
+		{Field: "AppName", Live: appName, Desired: desired.AppName},
+		{Field: "AppVersion", Live: appVersion, Desired: desired.AppVersion},
+		{Field: "AppCatalog", Live: appCatalog, Desired: desired.AppCatalog},
+		{Field: "DisableForceUpgrade", Live: strconv.FormatBool(disableForceUpgrade), Desired: strconv.FormatBool(desired.DisableForceUpgrade)},
+		{Field: "ConfigRef", Live: configRef, Desired: desired.ConfigRef},
+		{Field: "SourcePath", Live: sourcePath, Desired: desiredSourcePath},
+		{Field: "AppDestinationNamespace", Live: namespace, Desired: desired.AppDestinationNamespace},
+		{Field: "AppDestinationServer", Live: server, Desired: desiredServer},
+		{Field: "AppDestinationName", Live: name, Desired: desired.AppDestinationName},
+	} {
+		if f.Live != f.Desired {
+			diff = append(diff, f)
+		}
+	}
+	diff = append(diff, extraPluginEnvDiff(env, desired.ExtraPluginEnv)...)
+
+	return diff, len(diff) > 0, nil
+}
+
+// extraPluginEnvDiff compares the plugin env variables of the live
+// Application which are not generated from the other ApplicationConfig fields
+// with the desired ExtraPluginEnv.
+func extraPluginEnvDiff(env map[string]string, desired map[string]string) Diff {
+	names := map[string]bool{}
+	for name := range env {
+		switch name {
+		case pluginEnvAppName, pluginEnvAppVersion, pluginEnvAppCatalog, pluginEnvAppDisableForceUpgrade:
+			continue
+		}
+		names[name] = true
+	}
+	for name := range desired {
+		names[name] = true
+	}
+
+	// Sorted for stable output.
+	var sorted []string
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var diff Diff
+	for _, name := range sorted {
+		if env[name] != desired[name] {
+			diff = append(diff, FieldDiff{Field: fmt.Sprintf("ExtraPluginEnv[%s]", name), Live: env[name], Desired: desired[name]})
+		}
+	}
+
+	return diff
+}
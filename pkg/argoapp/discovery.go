@@ -0,0 +1,53 @@
+package argoapp
+
+import (
+	"github.com/giantswarm/microerror"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DiscoveryClient is the subset of
+// k8s.io/client-go/discovery.DiscoveryInterface needed to check which Argo CD
+// API versions are served by the cluster.
+type DiscoveryClient interface {
+	ServerResourcesForGroupVersion(groupVersion string) (*metav1.APIResourceList, error)
+}
+
+// CheckAPISupport checks that the cluster serves the Argo CD API version used
+// by NewApplication. It returns an error matched by IsUnsupportedCluster when
+// it doesn't.
+func CheckAPISupport(client DiscoveryClient) error {
+	_, err := NegotiateAPIVersion(client, argoAPIVersion)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// NegotiateAPIVersion returns the first of the given Argo CD API group
+// versions (e.g. "argoproj.io/v1alpha1") which is served by the cluster and
+// has the Application kind. It returns an error matched by
+// IsUnsupportedCluster when none of them is served.
+func NegotiateAPIVersion(client DiscoveryClient, groupVersions ...string) (string, error) {
+	if len(groupVersions) == 0 {
+		return "", microerror.Maskf(invalidConfigError, "at least one group version must be given")
+	}
+
+	for _, gv := range groupVersions {
+		list, err := client.ServerResourcesForGroupVersion(gv)
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return "", microerror.Mask(err)
+		}
+
+		for _, r := range list.APIResources {
+			if r.Kind == argoApplicationKind {
+				return gv, nil
+			}
+		}
+	}
+
+	return "", microerror.Maskf(unsupportedClusterError, "cluster does not serve kind %#q in any of group versions %v", argoApplicationKind, groupVersions)
+}
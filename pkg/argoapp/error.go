@@ -10,3 +10,21 @@ var invalidConfigError = &microerror.Error{
 func IsInvalidConfig(err error) bool {
 	return microerror.Cause(err) == invalidConfigError
 }
+
+var unsupportedClusterError = &microerror.Error{
+	Kind: "unsupportedClusterError",
+}
+
+// IsUnsupportedCluster asserts unsupportedClusterError.
+func IsUnsupportedCluster(err error) bool {
+	return microerror.Cause(err) == unsupportedClusterError
+}
+
+var verificationFailedError = &microerror.Error{
+	Kind: "verificationFailedError",
+}
+
+// IsVerificationFailed asserts verificationFailedError.
+func IsVerificationFailed(err error) bool {
+	return microerror.Cause(err) == verificationFailedError
+}
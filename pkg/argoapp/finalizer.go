@@ -0,0 +1,40 @@
+package argoapp
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// SetCascadedDeletion sets the Argo CD resources finalizer on the
+// Application, so its resources are deleted when it is deleted.
+func SetCascadedDeletion(obj *unstructured.Unstructured) {
+	if IsCascadedDeletion(obj) {
+		return
+	}
+
+	obj.SetFinalizers(append(obj.GetFinalizers(), argoResourceFinalizer))
+}
+
+// UnsetCascadedDeletion removes the Argo CD resources finalizer from the
+// Application, so its resources are left in the cluster when it is deleted.
+func UnsetCascadedDeletion(obj *unstructured.Unstructured) {
+	var finalizers []string
+	for _, f := range obj.GetFinalizers() {
+		if f != argoResourceFinalizer {
+			finalizers = append(finalizers, f)
+		}
+	}
+
+	obj.SetFinalizers(finalizers)
+}
+
+// IsCascadedDeletion returns true when the Application has the Argo CD
+// resources finalizer set.
+func IsCascadedDeletion(obj *unstructured.Unstructured) bool {
+	for _, f := range obj.GetFinalizers() {
+		if f == argoResourceFinalizer {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,20 @@
+package argoapp
+
+import "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+// FrozenAnnotation excludes the Application from the fleet-wide changes made
+// by the update helpers of packages argoappclient and jobs, e.g. during a
+// customer specific investigation. Its value is the reason of the freeze,
+// e.g. "investigating INC-1234", reported by the helpers skipping the
+// Application:
+//
+//	kubectl annotate application my-app argoapp.giantswarm.io/frozen="investigating INC-1234"
+//
+// The annotation is not set by the generator, so applying a generated
+// Application does not remove it.
+const FrozenAnnotation = "argoapp.giantswarm.io/frozen"
+
+// IsFrozen returns true when the object has a non-empty FrozenAnnotation.
+func IsFrozen(obj *unstructured.Unstructured) bool {
+	return obj.GetAnnotations()[FrozenAnnotation] != ""
+}
@@ -0,0 +1,322 @@
+package argoapp
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Generator generates Argo CD Application CRs. It builds the parts of the
+// object which do not depend on ApplicationConfig (project, source repository,
+// plugin skeleton, sync policy) only once and fills the per-config fields on
+// each call. This makes it cheaper than building the whole object from
+// scratch in reconciliation loops.
+//
+// The templates are cached at the package level by Defaults, so creating
+// many short-lived Generators, e.g. in webhook handlers, is cheap. See
+// ResetCaches.
+//
+// Generator is safe for concurrent use.
+type Generator struct {
+	template *unstructured.Unstructured
+	verifier Verifier
+	// syncPolicy is used instead of the default sync policy when set. See
+	// WithSyncPolicy.
+	syncPolicy *SyncPolicy
+}
+
+// Settings configures a Generator instance.
+type Settings struct {
+	// Defaults for the Argo CD instance the generator generates Application
+	// CRs for. Empty fields are taken from the package level Defaults at the
+	// time NewGenerator is called. See SetDefaults.
+	Defaults Defaults
+	// Verifier is optional. When set, the app is verified before the
+	// Application CR is generated and an error matched by
+	// IsVerificationFailed is returned when the verification fails.
+	Verifier Verifier
+}
+
+// NewGenerator returns an instance scoped Generator. Unlike NewApplication it
+// does not depend on the package level state after it is created so a single
+// process can generate Application CRs for multiple Argo CD instances.
+func NewGenerator(settings Settings) (*Generator, error) {
+	d := settings.Defaults.merge(GetDefaults())
+
+	if errs := validation.IsDNS1123Label(d.ArgoNamespace); len(errs) > 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Defaults.ArgoNamespace %#q is invalid: %s", settings, d.ArgoNamespace, strings.Join(errs, ", "))
+	}
+	if d.PluginMode != PluginModeLegacy && d.PluginMode != PluginModeSidecar {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Defaults.PluginMode %#q is unknown", settings, d.PluginMode)
+	}
+
+	g := newGenerator(d)
+	g.verifier = settings.Verifier
+
+	return g, nil
+}
+
+func newGenerator(d Defaults) *Generator {
+	g := &Generator{
+		template: getTemplate(d),
+	}
+
+	return g
+}
+
+func (g *Generator) NewApplication(config ApplicationConfig) (*unstructured.Unstructured, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if g.verifier != nil {
+		err := g.verifier.Verify(config)
+		if err != nil {
+			return nil, microerror.Maskf(verificationFailedError, "app %#q version %#q from catalog %#q: %s", config.AppName, config.AppVersion, config.AppCatalog, err)
+		}
+	}
+
+	obj := g.template.DeepCopy()
+	obj.SetName(config.Name)
+	if config.DisableCascadeDelete {
+		UnsetCascadedDeletion(obj)
+	}
+	if config.ArgoNamespace != "" {
+		obj.SetNamespace(config.ArgoNamespace)
+	}
+
+	annotations := map[string]string{}
+	if config.TTL > 0 {
+		annotations[TTLAnnotation] = config.TTL.String()
+	}
+	if config.ConfigHash != "" {
+		annotations[ConfigHashAnnotation] = config.ConfigHash
+	}
+	if config.Protected {
+		annotations[ProtectedAnnotation] = "true"
+	}
+	if config.SourcePath != "" {
+		annotations[ManifestGeneratePathsAnnotation] = "."
+	}
+	if config.SyncWave != 0 {
+		annotations[SyncWaveAnnotation] = strconv.Itoa(config.SyncWave)
+	}
+	if config.HookType != "" {
+		annotations[HookAnnotation] = string(config.HookType)
+	}
+	for k, v := range notificationAnnotations(config.Notifications) {
+		annotations[k] = v
+	}
+	if len(annotations) > 0 {
+		obj.SetAnnotations(annotations)
+	}
+
+	if config.ArgoProject != "" {
+		err := unstructured.SetNestedField(obj.Object, config.ArgoProject, "spec", "project")
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	err = unstructured.SetNestedField(obj.Object, config.ConfigRef, "spec", "source", "targetRevision")
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if config.SourcePath != "" {
+		err = unstructured.SetNestedField(obj.Object, config.SourcePath, "spec", "source", "path")
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+	err = unstructured.SetNestedSlice(obj.Object, newPluginEnv(config), "spec", "source", "plugin", "env")
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if config.AppDestinationNamespace != "" {
+		err = unstructured.SetNestedField(obj.Object, config.AppDestinationNamespace, "spec", "destination", "namespace")
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+	if config.AppDestinationServer != "" {
+		err = unstructured.SetNestedField(obj.Object, config.AppDestinationServer, "spec", "destination", "server")
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+	if config.AppDestinationName != "" {
+		unstructured.RemoveNestedField(obj.Object, "spec", "destination", "server")
+		err = unstructured.SetNestedField(obj.Object, config.AppDestinationName, "spec", "destination", "name")
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	if config.RevisionHistoryLimit != nil {
+		err = unstructured.SetNestedField(obj.Object, *config.RevisionHistoryLimit, "spec", "revisionHistoryLimit")
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+	if len(config.Info) > 0 {
+		err = unstructured.SetNestedSlice(obj.Object, newInfo(config.Info), "spec", "info")
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	if config.SyncPolicyPreset != "" || g.syncPolicy != nil {
+		policy, err := getSyncPolicy(config.SyncPolicyPreset)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		if config.SyncPolicyPreset == "" {
+			policy = *g.syncPolicy
+		}
+		err = unstructured.SetNestedMap(obj.Object, policy.toUnstructured(), "spec", "syncPolicy")
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	if config.Retry != nil {
+		err = unstructured.SetNestedMap(obj.Object, config.Retry.toUnstructured(), "spec", "syncPolicy", "retry")
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	syncOptions := config.SyncOptions
+	if config.AppNamespaceCreation {
+		syncOptions = appendSyncOption(syncOptions, "CreateNamespace=true")
+	}
+	if len(config.OwnershipLabels) > 0 {
+		labels := map[string]interface{}{}
+		for k, v := range config.OwnershipLabels {
+			labels[k] = v
+		}
+		err = unstructured.SetNestedMap(obj.Object, labels, "spec", "syncPolicy", "managedNamespaceMetadata", "labels")
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		// managedNamespaceMetadata is only applied to namespaces created
+		// by Argo CD.
+		syncOptions = appendSyncOption(syncOptions, "CreateNamespace=true")
+	}
+	if len(syncOptions) > 0 {
+		err = unstructured.SetNestedStringSlice(obj.Object, syncOptions, "spec", "syncPolicy", "syncOptions")
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	err = SealIntegrity(obj)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	RemoveEmptyFields(obj)
+
+	return obj, nil
+}
+
+// newApplicationTemplate returns the Application object with all the fields
+// which do not depend on ApplicationConfig set.
+//
+// NOTE: Slices are []interface{} rather than typed slices so the object can
+// be deep copied with runtime.DeepCopyJSON.
+func newApplicationTemplate(d Defaults) *unstructured.Unstructured {
+	// See the argo-cd source for detailed object structure:
+	// https://github.com/argoproj/argo-cd/blob/master/pkg/apis/application/v1alpha1/types.go
+	obj := map[string]interface{}{
+		"apiVersion": argoAPIVersion,
+		"kind":       argoApplicationKind,
+		"metadata": map[string]interface{}{
+			"namespace": d.ArgoNamespace,
+			"labels": map[string]interface{}{
+				ManagedByLabel: ManagedByLabelValue,
+			},
+			"finalizers": []interface{}{
+				argoResourceFinalizer,
+			},
+		},
+		"spec": map[string]interface{}{
+			"project": d.Project,
+			"source": map[string]interface{}{
+				"repoURL": d.ConfigRepoURL,
+				"path":    ".",
+				"plugin": map[string]interface{}{
+					"name": d.PluginName,
+				},
+			},
+			"destination": map[string]interface{}{
+				"server": inClusterServer,
+			},
+			"syncPolicy": defaultSyncPolicy.toUnstructured(),
+		},
+	}
+
+	if d.PluginMode == PluginModeSidecar {
+		unstructured.RemoveNestedField(obj, "spec", "source", "plugin", "name")
+	}
+
+	return &unstructured.Unstructured{Object: obj}
+}
+
+func newPluginEnv(config ApplicationConfig) []interface{} {
+	env := []interface{}{
+		map[string]interface{}{
+			"name":  pluginEnvAppName,
+			"value": config.AppName,
+		},
+		map[string]interface{}{
+			"name":  pluginEnvAppVersion,
+			"value": config.AppVersion,
+		},
+		map[string]interface{}{
+			"name":  pluginEnvAppCatalog,
+			"value": config.AppCatalog,
+		},
+	}
+
+	if config.DisableForceUpgrade {
+		env = append(env, map[string]interface{}{
+			"name":  pluginEnvAppDisableForceUpgrade,
+			"value": "true",
+		})
+	}
+
+	// Sorted for stable output.
+	var names []string
+	for name := range config.ExtraPluginEnv {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		env = append(env, map[string]interface{}{
+			"name":  name,
+			"value": config.ExtraPluginEnv[name],
+		})
+	}
+
+	return env
+}
+
+// appendSyncOption appends the sync option unless an option with the same key
+// is already set.
+func appendSyncOption(options []string, option string) []string {
+	key := strings.SplitN(option, "=", 2)[0]
+	for _, o := range options {
+		if strings.SplitN(o, "=", 2)[0] == key {
+			return options
+		}
+	}
+
+	return append(append([]string{}, options...), option)
+}
@@ -0,0 +1,76 @@
+package argoapp
+
+import (
+	"strconv"
+	"testing"
+)
+
+func Test_NewApplication_DisableForceUpgrade(t *testing.T) {
+	testCases := []struct {
+		name                string
+		disableForceUpgrade bool
+		expectedEnv         map[string]string
+	}{
+		{
+			name:                "case 0: force upgrades enabled",
+			disableForceUpgrade: false,
+			expectedEnv: map[string]string{
+				pluginEnvAppName:    "hello-world",
+				pluginEnvAppVersion: "1.2.3",
+				pluginEnvAppCatalog: "giantswarm",
+			},
+		},
+		{
+			name:                "case 1: force upgrades disabled",
+			disableForceUpgrade: true,
+			expectedEnv: map[string]string{
+				pluginEnvAppName:                "hello-world",
+				pluginEnvAppVersion:             "1.2.3",
+				pluginEnvAppCatalog:             "giantswarm",
+				pluginEnvAppDisableForceUpgrade: "true",
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			config := ApplicationConfig{
+				Name:                    "hello-world",
+				AppName:                 "hello-world",
+				AppVersion:              "1.2.3",
+				AppCatalog:              "giantswarm",
+				AppDestinationNamespace: "hello-world",
+				ConfigRef:               "main",
+				DisableForceUpgrade:     tc.disableForceUpgrade,
+			}
+
+			obj, err := NewApplication(config)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			env := pluginEnv(obj)
+			if len(env) != len(tc.expectedEnv) {
+				t.Fatalf("expected plugin env %v, got %v", tc.expectedEnv, env)
+			}
+			for k, v := range tc.expectedEnv {
+				if env[k] != v {
+					t.Fatalf("expected plugin env %#q to be %#q, got %#q", k, v, env[k])
+				}
+			}
+
+			parsed, err := ParseApplicationConfig(obj)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if parsed.DisableForceUpgrade != tc.disableForceUpgrade {
+				t.Fatalf("expected parsed DisableForceUpgrade %t, got %t", tc.disableForceUpgrade, parsed.DisableForceUpgrade)
+			}
+			if len(parsed.ExtraPluginEnv) != 0 {
+				t.Fatalf("expected no parsed ExtraPluginEnv, got %v", parsed.ExtraPluginEnv)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package argoapp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+type GitApplicationConfig struct {
+	// Name of the Argo CD Application CR.
+	Name string
+	// ArgoNamespace defaults to Defaults.ArgoNamespace.
+	ArgoNamespace string
+	// ArgoProject defaults to Defaults.Project.
+	ArgoProject string
+
+	// RepoURL is the git repository URL.
+	RepoURL string
+	// Path is the directory with the manifests in the repository.
+	Path string
+	// TargetRevision is the git ref. Defaults to HEAD.
+	TargetRevision string
+
+	// Recurse includes the manifests in the subdirectories of Path.
+	Recurse bool
+	// Include is a glob pattern of the manifest files to include, e.g.
+	// "{*.yml,*.yaml}".
+	Include string
+	// Exclude is a glob pattern of the manifest files to exclude.
+	Exclude string
+
+	DestinationNamespace string
+	// DestinationServer defaults to the in-cluster server. Only one of
+	// DestinationServer and DestinationName can be set.
+	DestinationServer string
+	// DestinationName is the cluster name as registered in Argo CD. Only
+	// one of DestinationServer and DestinationName can be set.
+	DestinationName string
+
+	// SyncPolicyPreset is the name of a registered sync policy preset.
+	SyncPolicyPreset string
+}
+
+type KustomizeApplicationConfig struct {
+	// Name of the Argo CD Application CR.
+	Name string
+	// ArgoNamespace defaults to Defaults.ArgoNamespace.
+	ArgoNamespace string
+	// ArgoProject defaults to Defaults.Project.
+	ArgoProject string
+
+	// RepoURL is the git repository URL.
+	RepoURL string
+	// Path is the directory with the kustomization in the repository.
+	Path string
+	// TargetRevision is the git ref. Defaults to HEAD.
+	TargetRevision string
+
+	// NamePrefix is prepended to the names of the resources.
+	NamePrefix string
+	// NameSuffix is appended to the names of the resources.
+	NameSuffix string
+	// Images override the container images, e.g. "nginx=nginx:1.21".
+	Images []string
+	// CommonLabels are added to all the resources.
+	CommonLabels map[string]string
+	// CommonAnnotations are added to all the resources.
+	CommonAnnotations map[string]string
+	// Version is the kustomize version configured in Argo CD.
+	Version string
+
+	DestinationNamespace string
+	// DestinationServer defaults to the in-cluster server. Only one of
+	// DestinationServer and DestinationName can be set.
+	DestinationServer string
+	// DestinationName is the cluster name as registered in Argo CD. Only
+	// one of DestinationServer and DestinationName can be set.
+	DestinationName string
+
+	// SyncPolicyPreset is the name of a registered sync policy preset.
+	SyncPolicyPreset string
+}
+
+// NewGitApplication returns an Application deploying the plain manifests
+// from a git repository directory.
+func NewGitApplication(config GitApplicationConfig) (*unstructured.Unstructured, error) {
+	if config.RepoURL == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.RepoURL must not be empty", config)
+	}
+	if config.Path == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Path must not be empty", config)
+	}
+
+	directory := map[string]interface{}{}
+	if config.Recurse {
+		directory["recurse"] = true
+	}
+	if config.Include != "" {
+		directory["include"] = config.Include
+	}
+	if config.Exclude != "" {
+		directory["exclude"] = config.Exclude
+	}
+
+	source := newGitSource(config.RepoURL, config.Path, config.TargetRevision)
+	source["directory"] = directory
+
+	c := sourceApplicationConfig{
+		configType: fmt.Sprintf("%T", config),
+
+		name:                 config.Name,
+		argoNamespace:        config.ArgoNamespace,
+		argoProject:          config.ArgoProject,
+		destinationNamespace: config.DestinationNamespace,
+		destinationServer:    config.DestinationServer,
+		destinationName:      config.DestinationName,
+		syncPolicyPreset:     config.SyncPolicyPreset,
+	}
+
+	obj, err := newSourceApplication(c, source)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return obj, nil
+}
+
+// NewKustomizeApplication returns an Application deploying a kustomization
+// from a git repository directory.
+func NewKustomizeApplication(config KustomizeApplicationConfig) (*unstructured.Unstructured, error) {
+	if config.RepoURL == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.RepoURL must not be empty", config)
+	}
+	if config.Path == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Path must not be empty", config)
+	}
+	for k, v := range config.CommonLabels {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return nil, microerror.Maskf(invalidConfigError, "%T.CommonLabels key %#q is invalid: %s", config, k, strings.Join(errs, ", "))
+		}
+		if errs := validation.IsValidLabelValue(v); len(errs) > 0 {
+			return nil, microerror.Maskf(invalidConfigError, "%T.CommonLabels[%#q] value %#q is invalid: %s", config, k, v, strings.Join(errs, ", "))
+		}
+	}
+	for k := range config.CommonAnnotations {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return nil, microerror.Maskf(invalidConfigError, "%T.CommonAnnotations key %#q is invalid: %s", config, k, strings.Join(errs, ", "))
+		}
+	}
+
+	kustomize := map[string]interface{}{
+		"images":            toInterfaceSlice(config.Images),
+		"commonLabels":      toInterfaceMap(config.CommonLabels),
+		"commonAnnotations": toInterfaceMap(config.CommonAnnotations),
+	}
+	if config.NamePrefix != "" {
+		kustomize["namePrefix"] = config.NamePrefix
+	}
+	if config.NameSuffix != "" {
+		kustomize["nameSuffix"] = config.NameSuffix
+	}
+	if config.Version != "" {
+		kustomize["version"] = config.Version
+	}
+
+	source := newGitSource(config.RepoURL, config.Path, config.TargetRevision)
+	source["kustomize"] = kustomize
+
+	c := sourceApplicationConfig{
+		configType: fmt.Sprintf("%T", config),
+
+		name:                 config.Name,
+		argoNamespace:        config.ArgoNamespace,
+		argoProject:          config.ArgoProject,
+		destinationNamespace: config.DestinationNamespace,
+		destinationServer:    config.DestinationServer,
+		destinationName:      config.DestinationName,
+		syncPolicyPreset:     config.SyncPolicyPreset,
+	}
+
+	obj, err := newSourceApplication(c, source)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return obj, nil
+}
+
+func newGitSource(repoURL, path, targetRevision string) map[string]interface{} {
+	source := map[string]interface{}{
+		"repoURL": repoURL,
+		"path":    path,
+	}
+	if targetRevision != "" {
+		source["targetRevision"] = targetRevision
+	}
+
+	return source
+}
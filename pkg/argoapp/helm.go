@@ -0,0 +1,120 @@
+package argoapp
+
+import (
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+type HelmApplicationConfig struct {
+	// Name of the Argo CD Application CR.
+	Name string
+	// ArgoNamespace defaults to Defaults.ArgoNamespace.
+	ArgoNamespace string
+	// ArgoProject defaults to Defaults.Project.
+	ArgoProject string
+
+	// RepoURL is the Helm repository URL, e.g.
+	// "https://giantswarm.github.io/giantswarm-catalog".
+	RepoURL string
+	// Chart is the chart name in the repository.
+	Chart string
+	// TargetRevision is the chart version.
+	TargetRevision string
+
+	// ReleaseName defaults to the Application name.
+	ReleaseName string
+	// Values is a YAML string of values.
+	Values string
+	// ValueFiles are values files from the chart.
+	ValueFiles []string
+	// Parameters override values.
+	Parameters []HelmParameter
+
+	DestinationNamespace string
+	// DestinationServer defaults to the in-cluster server. Only one of
+	// DestinationServer and DestinationName can be set.
+	DestinationServer string
+	// DestinationName is the cluster name as registered in Argo CD. Only
+	// one of DestinationServer and DestinationName can be set.
+	DestinationName string
+
+	// SyncPolicyPreset is the name of a registered sync policy preset.
+	SyncPolicyPreset string
+}
+
+type HelmParameter struct {
+	Name  string
+	Value string
+	// ForceString makes Helm treat the value as a string.
+	ForceString bool
+}
+
+// NewHelmApplication returns an Application deploying a chart directly from
+// a Helm repository, without the konfigure plugin.
+func NewHelmApplication(config HelmApplicationConfig) (*unstructured.Unstructured, error) {
+	if config.RepoURL == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.RepoURL must not be empty", config)
+	}
+	if config.Chart == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Chart must not be empty", config)
+	}
+	if config.TargetRevision == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.TargetRevision must not be empty", config)
+	}
+	for i, p := range config.Parameters {
+		if p.Name == "" {
+			return nil, microerror.Maskf(invalidConfigError, "%T.Parameters[%d].Name must not be empty", config, i)
+		}
+	}
+
+	var parameters []interface{}
+	for _, p := range config.Parameters {
+		parameter := map[string]interface{}{
+			"name":  p.Name,
+			"value": p.Value,
+		}
+		if p.ForceString {
+			parameter["forceString"] = true
+		}
+		parameters = append(parameters, parameter)
+	}
+
+	helm := map[string]interface{}{
+		"valueFiles": toInterfaceSlice(config.ValueFiles),
+		"parameters": parameters,
+	}
+	if config.ReleaseName != "" {
+		helm["releaseName"] = config.ReleaseName
+	}
+	if config.Values != "" {
+		helm["values"] = config.Values
+	}
+
+	source := map[string]interface{}{
+		"repoURL":        config.RepoURL,
+		"chart":          config.Chart,
+		"targetRevision": config.TargetRevision,
+		"helm":           helm,
+	}
+
+	c := sourceApplicationConfig{
+		configType: fmt.Sprintf("%T", config),
+
+		name:                 config.Name,
+		argoNamespace:        config.ArgoNamespace,
+		argoProject:          config.ArgoProject,
+		destinationNamespace: config.DestinationNamespace,
+		destinationServer:    config.DestinationServer,
+		destinationName:      config.DestinationName,
+		syncPolicyPreset:     config.SyncPolicyPreset,
+	}
+
+	obj, err := newSourceApplication(c, source)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return obj, nil
+}
@@ -0,0 +1,21 @@
+package argoapp
+
+// InfoEntry is an Application info entry shown in the Argo CD UI.
+type InfoEntry struct {
+	// Name is the entry title, e.g. "Owner" or "Runbook".
+	Name string
+	// Value is the entry value. URLs are rendered as links.
+	Value string
+}
+
+func newInfo(entries []InfoEntry) []interface{} {
+	var info []interface{}
+	for _, e := range entries {
+		info = append(info, map[string]interface{}{
+			"name":  e.Name,
+			"value": e.Value,
+		})
+	}
+
+	return info
+}
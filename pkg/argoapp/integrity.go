@@ -0,0 +1,137 @@
+package argoapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// IntegrityAnnotation records the labels and annotations set when the
+// Application was generated, so manual removals and edits can be detected
+// with IntegrityProblems and reverted with RepairIntegrity.
+const IntegrityAnnotation = "argoapp.giantswarm.io/integrity"
+
+type integrityRecord struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// SealIntegrity records the current labels and annotations of the object in
+// the IntegrityAnnotation. The generators seal the Applications they return,
+// callers adding labels or annotations to them, e.g. to select them later,
+// must seal them again before applying them so changes of these are detected
+// too.
+func SealIntegrity(obj *unstructured.Unstructured) error {
+	annotations := obj.GetAnnotations()
+	delete(annotations, IntegrityAnnotation)
+
+	record := integrityRecord{
+		Labels:      obj.GetLabels(),
+		Annotations: annotations,
+	}
+
+	// Map keys are sorted by json.Marshal so the value is stable.
+	data, err := json.Marshal(record)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[IntegrityAnnotation] = string(data)
+	obj.SetAnnotations(annotations)
+
+	return nil
+}
+
+// IntegrityProblems describes the labels and annotations recorded in the
+// IntegrityAnnotation which were removed or changed. Objects without the
+// IntegrityAnnotation have no problems.
+func IntegrityProblems(obj *unstructured.Unstructured) ([]string, error) {
+	record, ok, err := getIntegrityRecord(obj)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	} else if !ok {
+		return nil, nil
+	}
+
+	var problems []string
+	problems = append(problems, metadataProblems("label", record.Labels, obj.GetLabels())...)
+	problems = append(problems, metadataProblems("annotation", record.Annotations, obj.GetAnnotations())...)
+
+	return problems, nil
+}
+
+// RepairIntegrity returns a copy of the object with the labels and
+// annotations recorded in the IntegrityAnnotation restored. Other labels and
+// annotations are preserved.
+func RepairIntegrity(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	record, ok, err := getIntegrityRecord(obj)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	obj = obj.DeepCopy()
+	if !ok {
+		return obj, nil
+	}
+
+	if len(record.Labels) > 0 {
+		labels := obj.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		for k, v := range record.Labels {
+			labels[k] = v
+		}
+		obj.SetLabels(labels)
+	}
+
+	annotations := obj.GetAnnotations()
+	for k, v := range record.Annotations {
+		annotations[k] = v
+	}
+	obj.SetAnnotations(annotations)
+
+	return obj, nil
+}
+
+func getIntegrityRecord(obj *unstructured.Unstructured) (integrityRecord, bool, error) {
+	v, ok := obj.GetAnnotations()[IntegrityAnnotation]
+	if !ok {
+		return integrityRecord{}, false, nil
+	}
+
+	var record integrityRecord
+	err := json.Unmarshal([]byte(v), &record)
+	if err != nil {
+		return integrityRecord{}, false, microerror.Maskf(invalidConfigError, "annotation %#q value is invalid: %s", IntegrityAnnotation, err)
+	}
+
+	return record, true, nil
+}
+
+func metadataProblems(kind string, recorded, live map[string]string) []string {
+	// Sorted for stable output.
+	var keys []string
+	for k := range recorded {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var problems []string
+	for _, k := range keys {
+		v, ok := live[k]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s %#q was removed", kind, k))
+		} else if v != recorded[k] {
+			problems = append(problems, fmt.Sprintf("%s %#q was changed from %#q to %#q", kind, k, recorded[k], v))
+		}
+	}
+
+	return problems
+}
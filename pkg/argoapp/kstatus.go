@@ -0,0 +1,65 @@
+package argoapp
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// KStatus is the status of a resource as defined by kstatus
+// (sigs.k8s.io/cli-utils/pkg/kstatus), used by generic waiters like the
+// cli-utils and Flux health checks.
+type KStatus string
+
+const (
+	KStatusInProgress  KStatus = "InProgress"
+	KStatusFailed      KStatus = "Failed"
+	KStatusCurrent     KStatus = "Current"
+	KStatusTerminating KStatus = "Terminating"
+)
+
+// ComputeKStatus maps the Application sync, health and operation state to
+// its kstatus and a message describing it:
+//
+//   - Terminating when the Application is being deleted.
+//   - InProgress while an operation is running.
+//   - Failed when the last operation failed or the health is Degraded.
+//   - Current when it is Synced and Healthy or Suspended.
+//   - InProgress otherwise, e.g. while OutOfSync or Progressing.
+func ComputeKStatus(app *unstructured.Unstructured) (KStatus, string) {
+	if app.GetDeletionTimestamp() != nil {
+		return KStatusTerminating, "Application is being deleted"
+	}
+
+	sync, _, _ := unstructured.NestedString(app.Object, "status", "sync", "status")
+	health, _, _ := unstructured.NestedString(app.Object, "status", "health", "status")
+	healthMessage, _, _ := unstructured.NestedString(app.Object, "status", "health", "message")
+	phase, _, _ := unstructured.NestedString(app.Object, "status", "operationState", "phase")
+	operationMessage, _, _ := unstructured.NestedString(app.Object, "status", "operationState", "message")
+
+	switch phase {
+	case "Running", "Terminating":
+		return KStatusInProgress, fmt.Sprintf("operation is %s", phase)
+	case "Failed", "Error":
+		if sync != "Synced" {
+			return KStatusFailed, fmt.Sprintf("operation %s: %s", phase, operationMessage)
+		}
+	}
+
+	if health == "Degraded" {
+		return KStatusFailed, fmt.Sprintf("health is Degraded: %s", healthMessage)
+	}
+
+	if sync == "Synced" && (health == "Healthy" || health == "Suspended") {
+		return KStatusCurrent, fmt.Sprintf("Synced and %s", health)
+	}
+
+	if sync == "" {
+		sync = "Unknown"
+	}
+	if health == "" {
+		health = "Unknown"
+	}
+
+	return KStatusInProgress, fmt.Sprintf("sync is %s and health is %s", sync, health)
+}
@@ -0,0 +1,183 @@
+package argoapp
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const defaultRevisionHistoryLimit int64 = 10
+
+// LintRules are the rules checked by Lint.
+var LintRules = []string{
+	"retry",
+	"self-heal",
+	"revision-history-limit",
+	"finalizer",
+	"ignore-differences",
+}
+
+// noisyKinds maps the kinds whose fields are commonly changed in the cluster
+// to the ignoreDifferences entry which stops them showing as OutOfSync.
+var noisyKinds = map[string]map[string]interface{}{
+	"MutatingWebhookConfiguration": {
+		"group":             "admissionregistration.k8s.io",
+		"kind":              "MutatingWebhookConfiguration",
+		"jqPathExpressions": []interface{}{".webhooks[]?.clientConfig.caBundle"},
+	},
+	"ValidatingWebhookConfiguration": {
+		"group":             "admissionregistration.k8s.io",
+		"kind":              "ValidatingWebhookConfiguration",
+		"jqPathExpressions": []interface{}{".webhooks[]?.clientConfig.caBundle"},
+	},
+	"CustomResourceDefinition": {
+		"group":        "apiextensions.k8s.io",
+		"kind":         "CustomResourceDefinition",
+		"jsonPointers": []interface{}{"/spec/conversion/webhook/clientConfig/caBundle"},
+	},
+}
+
+// Finding is a best practice an Application does not follow.
+type Finding struct {
+	// Rule identifies the checked best practice, e.g. "retry".
+	Rule    string
+	Message string
+	// Fix is the JSON merge patch fixing the finding. It is nil when the
+	// finding can not be fixed automatically.
+	Fix map[string]interface{}
+}
+
+// Lint checks the generated or live Application against best practices:
+//
+//   - "retry": automated syncs are retried.
+//   - "self-heal": pruning Applications also revert manual changes.
+//   - "revision-history-limit": revisionHistoryLimit is set.
+//   - "finalizer": the resources finalizer is set, so the Application
+//     resources are deleted with it.
+//   - "ignore-differences": fields of the managed kinds commonly changed in
+//     the cluster, e.g. webhook CA bundles, are ignored.
+//
+// Apply the fixes with ApplyLintFixes.
+func Lint(obj *unstructured.Unstructured) []Finding {
+	var findings []Finding
+
+	automated, hasAutomated, _ := unstructured.NestedMap(obj.Object, "spec", "syncPolicy", "automated")
+	_, hasRetry, _ := unstructured.NestedMap(obj.Object, "spec", "syncPolicy", "retry")
+	if hasAutomated && !hasRetry {
+		policy, _ := getSyncPolicy(PresetProduction)
+		findings = append(findings, Finding{
+			Rule:    "retry",
+			Message: "failed automated syncs are not retried",
+			Fix:     nestedPatch(policy.Retry.toUnstructured(), "spec", "syncPolicy", "retry"),
+		})
+	}
+
+	if prune, _ := automated["prune"].(bool); prune {
+		if selfHeal, _ := automated["selfHeal"].(bool); !selfHeal {
+			findings = append(findings, Finding{
+				Rule:    "self-heal",
+				Message: "manual changes are not reverted although removed resources are pruned",
+				Fix:     nestedPatch(true, "spec", "syncPolicy", "automated", "selfHeal"),
+			})
+		}
+	}
+
+	_, hasLimit, _ := unstructured.NestedFieldNoCopy(obj.Object, "spec", "revisionHistoryLimit")
+	if !hasLimit {
+		findings = append(findings, Finding{
+			Rule:    "revision-history-limit",
+			Message: "revisionHistoryLimit is not set",
+			Fix:     nestedPatch(defaultRevisionHistoryLimit, "spec", "revisionHistoryLimit"),
+		})
+	}
+
+	if !IsCascadedDeletion(obj) {
+		var fixed []interface{}
+		for _, f := range append(obj.GetFinalizers(), argoResourceFinalizer) {
+			fixed = append(fixed, f)
+		}
+		findings = append(findings, Finding{
+			Rule:    "finalizer",
+			Message: fmt.Sprintf("finalizer %#q is not set so the resources are not deleted with the Application", argoResourceFinalizer),
+			Fix:     nestedPatch(fixed, "metadata", "finalizers"),
+		})
+	}
+
+	ignoreDifferences, _, _ := unstructured.NestedSlice(obj.Object, "spec", "ignoreDifferences")
+	ignored := map[string]bool{}
+	for _, item := range ignoreDifferences {
+		if d, ok := item.(map[string]interface{}); ok {
+			kind, _ := d["kind"].(string)
+			ignored[kind] = true
+		}
+	}
+	resources, _, _ := unstructured.NestedSlice(obj.Object, "status", "resources")
+	var missing []interface{}
+	for _, item := range resources {
+		r, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		kind, _ := r["kind"].(string)
+		if d, ok := noisyKinds[kind]; ok && !ignored[kind] {
+			missing = append(missing, runtime.DeepCopyJSONValue(d))
+			ignored[kind] = true
+		}
+	}
+	if len(missing) > 0 {
+		findings = append(findings, Finding{
+			Rule:    "ignore-differences",
+			Message: fmt.Sprintf("%d managed kinds with fields changed in the cluster are not ignored", len(missing)),
+			Fix:     nestedPatch(append(ignoreDifferences, missing...), "spec", "ignoreDifferences"),
+		})
+	}
+
+	return findings
+}
+
+// ApplyLintFixes returns a copy of the object with the fixes of the findings
+// applied.
+func ApplyLintFixes(obj *unstructured.Unstructured, findings []Finding) *unstructured.Unstructured {
+	obj = obj.DeepCopy()
+
+	for _, f := range findings {
+		if f.Fix == nil {
+			continue
+		}
+		mergePatch(obj.Object, runtime.DeepCopyJSON(f.Fix))
+	}
+
+	return obj
+}
+
+// mergePatch applies the JSON merge patch (RFC 7386) to dst.
+func mergePatch(dst, patch map[string]interface{}) {
+	for k, v := range patch {
+		if v == nil {
+			delete(dst, k)
+			continue
+		}
+
+		p, ok := v.(map[string]interface{})
+		if !ok {
+			dst[k] = v
+			continue
+		}
+		d, ok := dst[k].(map[string]interface{})
+		if !ok {
+			d = map[string]interface{}{}
+			dst[k] = d
+		}
+		mergePatch(d, p)
+	}
+}
+
+// nestedPatch returns the JSON merge patch setting the field at path to v.
+func nestedPatch(v interface{}, path ...string) map[string]interface{} {
+	for i := len(path) - 1; i > 0; i-- {
+		v = map[string]interface{}{path[i]: v}
+	}
+
+	return map[string]interface{}{path[0]: v}
+}
@@ -0,0 +1,131 @@
+package argoapp
+
+import (
+	"bytes"
+	"io/ioutil"
+
+	"github.com/giantswarm/microerror"
+	"sigs.k8s.io/yaml"
+)
+
+// collectionFile is the format of the files read by LoadApplicationConfigs
+// with shared CollectionDefaults.
+type collectionFile struct {
+	Defaults     CollectionDefaults
+	Applications []ApplicationConfig
+}
+
+// LoadApplicationConfig reads a single ApplicationConfig from the YAML or
+// JSON file at path, e.g.:
+//
+//	Name: dex
+//	AppName: dex
+//	AppVersion: 1.2.3
+//	AppCatalog: giantswarm
+//	AppDestinationNamespace: dex
+//	ConfigRef: main
+//
+// The keys are the ApplicationConfig field names, matched case
+// insensitively. Unknown and duplicate keys are rejected. TTL is an integer
+// number of nanoseconds, e.g. 3600000000000 for one hour, as time.Duration
+// has no text encoding. The config is validated and an error matched by
+// IsInvalidConfig is returned when it is invalid.
+func LoadApplicationConfig(path string) (ApplicationConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ApplicationConfig{}, microerror.Mask(err)
+	}
+
+	var config ApplicationConfig
+	err = yaml.UnmarshalStrict(data, &config)
+	if err != nil {
+		return ApplicationConfig{}, microerror.Maskf(invalidConfigError, "file %#q: %s", path, err)
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return ApplicationConfig{}, microerror.Mask(err)
+	}
+
+	return config, nil
+}
+
+// LoadApplicationConfigs reads the ApplicationConfigs of a collection from
+// the YAML or JSON file at path, so collections can be declared in Git and
+// built by CI. The file is either a list of configs or an object with the
+// CollectionDefaults set on the configs leaving the fields empty:
+//
+//	Defaults:
+//	  AppCatalog: giantswarm
+//	  ConfigRef: main
+//	Applications:
+//	- Name: dex
+//	  AppName: dex
+//	  AppVersion: 1.2.3
+//	  AppDestinationNamespace: dex
+//
+// The keys are matched and rejected like with LoadApplicationConfig. The
+// configs are validated like with NewApplicationCollection.
+func LoadApplicationConfigs(path string) ([]ApplicationConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	file, err := decodeCollectionFile(data)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "file %#q: %s", path, err)
+	}
+
+	configs, err := file.configs()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return configs, nil
+}
+
+// DecodeApplicationConfigs is like LoadApplicationConfigs but decodes the
+// YAML or JSON data, e.g. fetched over HTTP.
+func DecodeApplicationConfigs(data []byte) ([]ApplicationConfig, error) {
+	file, err := decodeCollectionFile(data)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "%s", err)
+	}
+
+	configs, err := file.configs()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return configs, nil
+}
+
+func decodeCollectionFile(data []byte) (collectionFile, error) {
+	data, err := yaml.YAMLToJSONStrict(data)
+	if err != nil {
+		return collectionFile{}, err
+	}
+
+	var file collectionFile
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		err = yaml.UnmarshalStrict(data, &file.Applications)
+	} else {
+		err = yaml.UnmarshalStrict(data, &file)
+	}
+	if err != nil {
+		return collectionFile{}, err
+	}
+
+	return file, nil
+}
+
+// configs returns the Applications with the Defaults set.
+func (f collectionFile) configs() ([]ApplicationConfig, error) {
+	configs, problems := withCollectionDefaults(f.Applications, f.Defaults)
+	if len(problems) > 0 {
+		return nil, microerror.Mask(&ValidationError{Problems: problems})
+	}
+
+	return configs, nil
+}
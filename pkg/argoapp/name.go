@@ -0,0 +1,65 @@
+package argoapp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// MaxNameLength is the maximum length of Application names. Argo CD sets the
+// Application name as a label value on the managed resources, so it is
+// limited to the 63 characters of a label value.
+const MaxNameLength = validation.DNS1123LabelMaxLength
+
+// nameHashLength is the length of the hash suffix of truncated names.
+const nameHashLength = 8
+
+// GenerateName returns a DNS-1123 compliant Application name of at most
+// MaxNameLength characters in the <cluster>-<appName>-<suffix> format. Empty
+// parts are left out and invalid characters are replaced with "-". Names
+// which are too long are truncated and suffixed with a hash of the parts, so
+// the same parts always result in the same name.
+func GenerateName(appName, cluster, suffix string) string {
+	var parts []string
+	for _, p := range []string{cluster, appName, suffix} {
+		if p = sanitizeName(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+
+	name := strings.Join(parts, "-")
+	if name != "" && len(name) <= MaxNameLength {
+		return name
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join([]string{cluster, appName, suffix}, "/")))
+	hash := hex.EncodeToString(sum[:])[:nameHashLength]
+	if max := MaxNameLength - nameHashLength - 1; len(name) > max {
+		name = strings.TrimRight(name[:max], "-")
+	}
+	if name == "" {
+		return hash
+	}
+
+	return name + "-" + hash
+}
+
+// sanitizeName lower cases s and replaces the characters not allowed in
+// DNS-1123 labels with single dashes.
+func sanitizeName(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash {
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+
+	return strings.Trim(b.String(), "-")
+}
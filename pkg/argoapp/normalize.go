@@ -0,0 +1,60 @@
+package argoapp
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// RemoveEmptyFields removes empty nested maps and slices (e.g. empty helm
+// blocks or syncOptions) from the object. This keeps the manifests minimal and
+// avoids spurious diffs against the objects stored by Argo CD, which omits
+// empty fields. Scalar values are kept even when they are zero values because
+// they can be meaningful, e.g. allowEmpty: false.
+func RemoveEmptyFields(obj *unstructured.Unstructured) {
+	removeEmptyFields(obj.Object)
+}
+
+func removeEmptyFields(m map[string]interface{}) {
+	for k, v := range m {
+		v = pruneValue(v)
+		if isEmptyValue(v) {
+			delete(m, k)
+			continue
+		}
+		m[k] = v
+	}
+}
+
+// pruneValue removes empty fields from v in place when v is a map or slice.
+// The returned value must be used for slices as they can shrink.
+func pruneValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		removeEmptyFields(v)
+		return v
+	case []interface{}:
+		var pruned []interface{}
+		for _, item := range v {
+			item = pruneValue(item)
+			if isEmptyValue(item) {
+				continue
+			}
+			pruned = append(pruned, item)
+		}
+		return pruned
+	default:
+		return v
+	}
+}
+
+func isEmptyValue(v interface{}) bool {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	case nil:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,78 @@
+package argoapp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// notificationsSubscribePrefix prefixes the Argo CD notifications
+// subscription annotations, e.g.
+// notifications.argoproj.io/subscribe.on-sync-failed.slack: my-channel.
+const notificationsSubscribePrefix = "notifications.argoproj.io/subscribe."
+
+// NotificationSubscription subscribes a channel to the notifications of the
+// Application sent by an Argo CD notifications trigger.
+type NotificationSubscription struct {
+	// Trigger is the notifications trigger, e.g. "on-sync-failed" or
+	// "on-health-degraded".
+	Trigger string
+	// Service is the notifications service, e.g. "slack" or "teams".
+	Service string
+	// Channel is the recipient of the service, e.g. the Slack channel.
+	Channel string
+}
+
+// notificationAnnotations returns the subscription annotations. The channels
+// of the same trigger and service are joined with ";".
+func notificationAnnotations(subscriptions []NotificationSubscription) map[string]string {
+	channels := map[string][]string{}
+	for _, s := range subscriptions {
+		key := notificationsSubscribePrefix + s.Trigger + "." + s.Service
+		channels[key] = append(channels[key], s.Channel)
+	}
+
+	annotations := map[string]string{}
+	for key, c := range channels {
+		sort.Strings(c)
+		annotations[key] = strings.Join(c, ";")
+	}
+
+	return annotations
+}
+
+func (s NotificationSubscription) problems(i int) []error {
+	var problems []error
+	add := func(reason error, format string, args ...interface{}) {
+		problems = append(problems, newProblem(reason, format, args...))
+	}
+	missing := func(field string) {
+		problems = append(problems, &MissingFieldError{Field: fmt.Sprintf("Notifications[%d].%s", i, field)})
+	}
+
+	if s.Trigger == "" {
+		missing("Trigger")
+	} else if strings.Contains(s.Trigger, ".") {
+		add(ErrInvalidValue, "%T.Notifications[%d].Trigger %#q must not contain \".\"", ApplicationConfig{}, i, s.Trigger)
+	}
+	if s.Service == "" {
+		missing("Service")
+	} else if strings.Contains(s.Service, ".") {
+		add(ErrInvalidValue, "%T.Notifications[%d].Service %#q must not contain \".\"", ApplicationConfig{}, i, s.Service)
+	}
+	if s.Channel == "" {
+		missing("Channel")
+	} else if strings.Contains(s.Channel, ";") {
+		add(ErrInvalidValue, "%T.Notifications[%d].Channel %#q must not contain \";\"", ApplicationConfig{}, i, s.Channel)
+	}
+	if len(problems) == 0 {
+		key := notificationsSubscribePrefix + s.Trigger + "." + s.Service
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			add(ErrInvalidValue, "%T.Notifications[%d] annotation %#q is invalid: %s", ApplicationConfig{}, i, key, strings.Join(errs, ", "))
+		}
+	}
+
+	return problems
+}
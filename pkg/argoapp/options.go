@@ -0,0 +1,98 @@
+package argoapp
+
+import (
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Option customizes the generation of a single Application CR with
+// NewApplicationWithOptions. Options set defaults, so the corresponding
+// ApplicationConfig fields take precedence over them.
+type Option func(o *options)
+
+type options struct {
+	defaults          Defaults
+	syncPolicy        *SyncPolicy
+	destinationServer string
+	destinationName   string
+}
+
+// WithProject sets the Argo CD project used when
+// ApplicationConfig.ArgoProject is empty.
+func WithProject(project string) Option {
+	return func(o *options) {
+		o.defaults.Project = project
+	}
+}
+
+// WithNamespace sets the namespace of the Application CR used when
+// ApplicationConfig.ArgoNamespace is empty.
+func WithNamespace(namespace string) Option {
+	return func(o *options) {
+		o.defaults.ArgoNamespace = namespace
+	}
+}
+
+// WithSyncPolicy sets the sync policy used when
+// ApplicationConfig.SyncPolicyPreset is empty. ApplicationConfig.Retry and
+// ApplicationConfig.SyncOptions are still applied on top of it.
+func WithSyncPolicy(policy SyncPolicy) Option {
+	return func(o *options) {
+		o.syncPolicy = &policy
+	}
+}
+
+// WithDestination sets the destination cluster used when neither
+// ApplicationConfig.AppDestinationServer nor
+// ApplicationConfig.AppDestinationName is set. Either the server URL or the
+// cluster name must be empty.
+func WithDestination(server, name string) Option {
+	return func(o *options) {
+		o.destinationServer = server
+		o.destinationName = name
+	}
+}
+
+// NewApplicationWithOptions is like NewApplication but customized with the
+// options. Unset options default to the package level Defaults.
+func NewApplicationWithOptions(config ApplicationConfig, opts ...Option) (*unstructured.Unstructured, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.destinationServer != "" && o.destinationName != "" {
+		return nil, microerror.Maskf(invalidConfigError, "destination server %#q and name %#q are mutually exclusive", o.destinationServer, o.destinationName)
+	}
+	if o.syncPolicy != nil && o.syncPolicy.Retry != nil {
+		if problems := o.syncPolicy.Retry.problems(); len(problems) > 0 {
+			return nil, microerror.Mask(&ValidationError{Problems: problems})
+		}
+	}
+
+	g := getDefaultGenerator()
+	if o.defaults != (Defaults{}) {
+		var err error
+		g, err = NewGenerator(Settings{Defaults: o.defaults})
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+	if o.syncPolicy != nil {
+		c := *g
+		c.syncPolicy = o.syncPolicy
+		g = &c
+	}
+
+	if config.AppDestinationServer == "" && config.AppDestinationName == "" {
+		config.AppDestinationServer = o.destinationServer
+		config.AppDestinationName = o.destinationName
+	}
+
+	obj, err := g.NewApplication(config)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return obj, nil
+}
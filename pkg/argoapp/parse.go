@@ -0,0 +1,170 @@
+package argoapp
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ParseApplicationConfig reads back the ApplicationConfig of an Application
+// generated by this package, e.g. for migration tooling and reconcilers
+// which need to know what was deployed. The app name, version and catalog
+// are taken from the konfigure plugin env and the ConfigRef of an
+// Application pinned with PinConfigRef is the one it was pinned from.
+//
+// SyncPolicyPreset can not be read back. The sync policy of the
+// Application is only reflected in the Retry and SyncOptions fields. An
+// error matched by IsInvalidConfig is returned when obj is not an
+// Application or has no konfigure plugin env.
+func ParseApplicationConfig(obj *unstructured.Unstructured) (ApplicationConfig, error) {
+	if obj.GetKind() != argoApplicationKind {
+		return ApplicationConfig{}, microerror.Maskf(invalidConfigError, "object %#q is a %#q, not an Application", obj.GetName(), obj.GetKind())
+	}
+
+	env := pluginEnv(obj)
+	if env[pluginEnvAppName] == "" {
+		return ApplicationConfig{}, microerror.Maskf(invalidConfigError, "Application %#q has no %#q plugin env", obj.GetName(), pluginEnvAppName)
+	}
+
+	config := ApplicationConfig{
+		Name:                obj.GetName(),
+		ArgoNamespace:       obj.GetNamespace(),
+		AppName:             env[pluginEnvAppName],
+		AppVersion:          env[pluginEnvAppVersion],
+		AppCatalog:          env[pluginEnvAppCatalog],
+		DisableForceUpgrade: env[pluginEnvAppDisableForceUpgrade] == "true",
+	}
+	for name, value := range env {
+		switch name {
+		case pluginEnvAppName, pluginEnvAppVersion, pluginEnvAppCatalog, pluginEnvAppDisableForceUpgrade:
+			continue
+		}
+		if config.ExtraPluginEnv == nil {
+			config.ExtraPluginEnv = map[string]string{}
+		}
+		config.ExtraPluginEnv[name] = value
+	}
+
+	config.ArgoProject, _, _ = unstructured.NestedString(obj.Object, "spec", "project")
+	config.ConfigRef, _, _ = unstructured.NestedString(obj.Object, "spec", "source", "targetRevision")
+	if IsPinned(obj) {
+		config.ConfigRef = obj.GetAnnotations()[PinnedFromAnnotation]
+	}
+	config.SourcePath, _, _ = unstructured.NestedString(obj.Object, "spec", "source", "path")
+	if config.SourcePath == "." {
+		config.SourcePath = ""
+	}
+
+	config.AppDestinationNamespace, _, _ = unstructured.NestedString(obj.Object, "spec", "destination", "namespace")
+	config.MultiNamespace = config.AppDestinationNamespace == ""
+	config.AppDestinationServer, _, _ = unstructured.NestedString(obj.Object, "spec", "destination", "server")
+	if config.AppDestinationServer == inClusterServer {
+		config.AppDestinationServer = ""
+	}
+	config.AppDestinationName, _, _ = unstructured.NestedString(obj.Object, "spec", "destination", "name")
+
+	config.DisableCascadeDelete = !IsCascadedDeletion(obj)
+
+	annotations := obj.GetAnnotations()
+	config.ConfigHash = annotations[ConfigHashAnnotation]
+	config.Protected = IsProtected(obj)
+	config.HookType = HookType(annotations[HookAnnotation])
+	if v, ok := annotations[SyncWaveAnnotation]; ok {
+		wave, err := strconv.Atoi(v)
+		if err != nil {
+			return ApplicationConfig{}, microerror.Maskf(invalidConfigError, "annotation %#q value %#q is invalid: %s", SyncWaveAnnotation, v, err)
+		}
+		config.SyncWave = wave
+	}
+	if v, ok := annotations[TTLAnnotation]; ok {
+		ttl, err := time.ParseDuration(v)
+		if err != nil {
+			return ApplicationConfig{}, microerror.Maskf(invalidConfigError, "annotation %#q value %#q is invalid: %s", TTLAnnotation, v, err)
+		}
+		config.TTL = ttl
+	}
+	config.Notifications = parseNotificationAnnotations(annotations)
+
+	if limit, ok, _ := unstructured.NestedInt64(obj.Object, "spec", "revisionHistoryLimit"); ok {
+		config.RevisionHistoryLimit = &limit
+	}
+	info, _, _ := unstructured.NestedSlice(obj.Object, "spec", "info")
+	for _, item := range info {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := entry["name"].(string)
+		value, _ := entry["value"].(string)
+		config.Info = append(config.Info, InfoEntry{Name: name, Value: value})
+	}
+
+	if retry, ok, _ := unstructured.NestedMap(obj.Object, "spec", "syncPolicy", "retry"); ok {
+		config.Retry = parseRetryStrategy(retry)
+	}
+	syncOptions, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "syncPolicy", "syncOptions")
+	for _, o := range syncOptions {
+		if o == "CreateNamespace=true" {
+			config.AppNamespaceCreation = true
+			continue
+		}
+		config.SyncOptions = append(config.SyncOptions, o)
+	}
+	config.OwnershipLabels, _, _ = unstructured.NestedStringMap(obj.Object, "spec", "syncPolicy", "managedNamespaceMetadata", "labels")
+
+	return config, nil
+}
+
+// parseNotificationAnnotations is the reverse of notificationAnnotations. The
+// subscriptions are sorted so the result is stable.
+func parseNotificationAnnotations(annotations map[string]string) []NotificationSubscription {
+	var subscriptions []NotificationSubscription
+	for k, v := range annotations {
+		if !strings.HasPrefix(k, notificationsSubscribePrefix) {
+			continue
+		}
+		split := strings.SplitN(strings.TrimPrefix(k, notificationsSubscribePrefix), ".", 2)
+		if len(split) != 2 {
+			continue
+		}
+		for _, channel := range strings.Split(v, ";") {
+			subscriptions = append(subscriptions, NotificationSubscription{
+				Trigger: split[0],
+				Service: split[1],
+				Channel: channel,
+			})
+		}
+	}
+
+	sort.Slice(subscriptions, func(i, j int) bool {
+		a, b := subscriptions[i], subscriptions[j]
+		if a.Trigger != b.Trigger {
+			return a.Trigger < b.Trigger
+		}
+		if a.Service != b.Service {
+			return a.Service < b.Service
+		}
+		return a.Channel < b.Channel
+	})
+
+	return subscriptions
+}
+
+// parseRetryStrategy is the reverse of RetryStrategy.toUnstructured.
+func parseRetryStrategy(retry map[string]interface{}) *RetryStrategy {
+	s := &RetryStrategy{}
+	s.Limit, _, _ = unstructured.NestedInt64(retry, "limit")
+
+	if backoff, ok, _ := unstructured.NestedMap(retry, "backoff"); ok {
+		s.Backoff = &Backoff{}
+		s.Backoff.Duration, _, _ = unstructured.NestedString(backoff, "duration")
+		s.Backoff.Factor, _, _ = unstructured.NestedInt64(backoff, "factor")
+		s.Backoff.MaxDuration, _, _ = unstructured.NestedString(backoff, "maxDuration")
+	}
+
+	return s
+}
@@ -0,0 +1,134 @@
+package argoapp
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// PinnedFromAnnotation holds the targetRevision, e.g. the floating major
+	// tag, the Application was pinned from with PinConfigRef.
+	PinnedFromAnnotation = "argoapp.giantswarm.io/pinned-from"
+	// PinnedAtAnnotation holds the RFC 3339 time the Application was
+	// pinned.
+	PinnedAtAnnotation = "argoapp.giantswarm.io/pinned-at"
+	// PinnedUntilAnnotation holds the RFC 3339 time after which the
+	// Application can be unpinned. See PinOptions.Duration.
+	PinnedUntilAnnotation = "argoapp.giantswarm.io/pinned-until"
+	// PinReasonAnnotation holds PinOptions.Reason.
+	PinReasonAnnotation = "argoapp.giantswarm.io/pin-reason"
+)
+
+var commitSHARegexp = regexp.MustCompile(`^([0-9a-f]{40}|[0-9a-f]{64})$`)
+
+type PinOptions struct {
+	// Reason is recorded in the PinReasonAnnotation, e.g. the incident
+	// reference.
+	Reason string
+	// Duration is optional. When set, the Application is unpinned by
+	// argoappclient.UnpinExpired after the Duration.
+	Duration time.Duration
+}
+
+// PinConfigRef returns a copy of the Application with the source
+// targetRevision pinned to the exact commit, e.g. to freeze the config during
+// an incident. The previous targetRevision is recorded in the
+// PinnedFromAnnotation so it can be restored with UnpinConfigRef. Pinning an
+// already pinned Application keeps the recorded targetRevision.
+func PinConfigRef(obj *unstructured.Unstructured, commit string, options PinOptions) (*unstructured.Unstructured, error) {
+	if !commitSHARegexp.MatchString(commit) {
+		return nil, microerror.Maskf(invalidConfigError, "commit %#q is not a full commit SHA", commit)
+	}
+	if options.Duration < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Duration must not be negative", options)
+	}
+
+	obj = obj.DeepCopy()
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	if !IsPinned(obj) {
+		targetRevision, _, err := unstructured.NestedString(obj.Object, "spec", "source", "targetRevision")
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		annotations[PinnedFromAnnotation] = targetRevision
+	}
+
+	now := time.Now().UTC()
+	annotations[PinnedAtAnnotation] = now.Format(time.RFC3339)
+	delete(annotations, PinnedUntilAnnotation)
+	if options.Duration > 0 {
+		annotations[PinnedUntilAnnotation] = now.Add(options.Duration).Format(time.RFC3339)
+	}
+	delete(annotations, PinReasonAnnotation)
+	if options.Reason != "" {
+		annotations[PinReasonAnnotation] = options.Reason
+	}
+	obj.SetAnnotations(annotations)
+
+	err := unstructured.SetNestedField(obj.Object, commit, "spec", "source", "targetRevision")
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return obj, nil
+}
+
+// UnpinConfigRef returns a copy of the Application with the targetRevision
+// recorded by PinConfigRef restored and the pin annotations removed. Not
+// pinned Applications are returned unchanged.
+func UnpinConfigRef(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	obj = obj.DeepCopy()
+
+	if !IsPinned(obj) {
+		return obj, nil
+	}
+
+	annotations := obj.GetAnnotations()
+
+	err := unstructured.SetNestedField(obj.Object, annotations[PinnedFromAnnotation], "spec", "source", "targetRevision")
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	delete(annotations, PinnedFromAnnotation)
+	delete(annotations, PinnedAtAnnotation)
+	delete(annotations, PinnedUntilAnnotation)
+	delete(annotations, PinReasonAnnotation)
+	obj.SetAnnotations(annotations)
+
+	return obj, nil
+}
+
+// IsPinned returns true when the Application was pinned with PinConfigRef.
+func IsPinned(obj *unstructured.Unstructured) bool {
+	_, ok := obj.GetAnnotations()[PinnedFromAnnotation]
+	return ok
+}
+
+// IsPinExpired returns true when the Application is pinned with the
+// PinnedUntilAnnotation set to a time before now.
+func IsPinExpired(obj *unstructured.Unstructured, now time.Time) (bool, error) {
+	if !IsPinned(obj) {
+		return false, nil
+	}
+
+	v, ok := obj.GetAnnotations()[PinnedUntilAnnotation]
+	if !ok {
+		return false, nil
+	}
+
+	pinnedUntil, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return false, microerror.Maskf(invalidConfigError, "annotation %#q value %#q is invalid: %s", PinnedUntilAnnotation, v, err)
+	}
+
+	return pinnedUntil.Before(now), nil
+}
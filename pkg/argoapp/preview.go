@@ -0,0 +1,74 @@
+package argoapp
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// PreviewLabel is set to "true" on preview Applications.
+	PreviewLabel = "argoapp.giantswarm.io/preview"
+	// PullRequestLabel is set to the pull request number on preview
+	// Applications.
+	PullRequestLabel = "argoapp.giantswarm.io/pull-request"
+)
+
+type PreviewConfig struct {
+	// Application is the config of the Application to preview. Its Name is
+	// suffixed with the pull request number and its ConfigRef is replaced
+	// with Branch.
+	Application ApplicationConfig
+
+	// PullRequest number.
+	PullRequest int
+	// Branch of the config repository the pull request is opened from.
+	Branch string
+	// TTL is how long the preview Application is kept.
+	TTL time.Duration
+}
+
+// NewPreviewApplication generates an ephemeral Application rendering the
+// config repository pull request branch. The Application expires after the
+// configured TTL. See ApplicationConfig.TTL.
+func NewPreviewApplication(config PreviewConfig) (*unstructured.Unstructured, error) {
+	if config.PullRequest <= 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.PullRequest must be positive", config)
+	}
+	if config.Branch == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Branch must not be empty", config)
+	}
+	if config.TTL <= 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.TTL must be positive", config)
+	}
+
+	app := config.Application
+	if app.Name != "" {
+		app.Name = GenerateName(app.Name, "", fmt.Sprintf("pr-%d", config.PullRequest))
+	}
+	app.ConfigRef = config.Branch
+	app.TTL = config.TTL
+
+	obj, err := NewApplication(app)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[PreviewLabel] = "true"
+	labels[PullRequestLabel] = strconv.Itoa(config.PullRequest)
+	obj.SetLabels(labels)
+
+	err = SealIntegrity(obj)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return obj, nil
+}
@@ -0,0 +1,105 @@
+package argoapp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+)
+
+var (
+	// ErrInvalidName is matched with errors.Is by the problems of names,
+	// e.g. the Application or namespace name, which are not valid.
+	ErrInvalidName = errors.New("invalid name")
+	// ErrUnsupportedOption is matched with errors.Is by the problems of
+	// unknown sync options, hook types and unregistered sync policy
+	// presets.
+	ErrUnsupportedOption = errors.New("unsupported option")
+	// ErrInvalidValue is matched with errors.Is by the other problems of
+	// field values.
+	ErrInvalidValue = errors.New("invalid value")
+)
+
+// MissingFieldError is the problem of a required ApplicationConfig field
+// which is empty. It is matched with errors.As.
+type MissingFieldError struct {
+	// Field is the field path, e.g. "ConfigRef" or "Info[0].Name".
+	Field string
+}
+
+func (e *MissingFieldError) Error() string {
+	return fmt.Sprintf("%T.%s must not be empty", ApplicationConfig{}, e.Field)
+}
+
+// ValidationError lists all the problems of invalid ApplicationConfigs. It
+// is matched by IsInvalidConfig. errors.Is and errors.As match its problems,
+// e.g.:
+//
+//	var missing *argoapp.MissingFieldError
+//	if errors.As(err, &missing) {
+//		// Highlight missing.Field in the form.
+//	}
+type ValidationError struct {
+	Problems []error
+}
+
+func (e *ValidationError) Error() string {
+	var problems []string
+	for _, p := range e.Problems {
+		problems = append(problems, p.Error())
+	}
+
+	return invalidConfigError.Error() + ": " + strings.Join(problems, "; ")
+}
+
+// Is reports whether any of the problems matches target.
+func (e *ValidationError) Is(target error) bool {
+	if target == error(invalidConfigError) {
+		return true
+	}
+	for _, p := range e.Problems {
+		if errors.Is(p, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As finds the first problem matching target. It also matches
+// invalidConfigError so the error is matched by IsInvalidConfig.
+func (e *ValidationError) As(target interface{}) bool {
+	if t, ok := target.(**microerror.Error); ok {
+		*t = invalidConfigError
+		return true
+	}
+	for _, p := range e.Problems {
+		if errors.As(p, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// problem is a problem of a config matched with errors.Is by its reason.
+type problem struct {
+	reason  error
+	message string
+}
+
+func newProblem(reason error, format string, args ...interface{}) error {
+	return &problem{
+		reason:  reason,
+		message: fmt.Sprintf(format, args...),
+	}
+}
+
+func (p *problem) Error() string {
+	return p.message
+}
+
+func (p *problem) Unwrap() error {
+	return p.reason
+}
@@ -0,0 +1,15 @@
+package argoapp
+
+import "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+// ProtectedAnnotation set to "true" protects the object from being deleted
+// by the prune, reap, garbage collection and delete helpers of package
+// argoappclient unless they are forced, e.g. for CNI or CSI apps. See
+// ApplicationConfig.Protected.
+const ProtectedAnnotation = "argoapp.giantswarm.io/protected"
+
+// IsProtected returns true when the object has the ProtectedAnnotation set
+// to "true".
+func IsProtected(obj *unstructured.Unstructured) bool {
+	return obj.GetAnnotations()[ProtectedAnnotation] == "true"
+}
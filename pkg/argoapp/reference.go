@@ -0,0 +1,60 @@
+package argoapp
+
+import (
+	"reflect"
+	"strings"
+)
+
+// FieldReference documents an ApplicationConfig field, e.g. to build form UIs
+// and validation front-ends on top of the library.
+type FieldReference struct {
+	// Name is the Go field name.
+	Name string `json:"name"`
+	// Type is the Go type, e.g. "string" or "map[string]string".
+	Type string `json:"type"`
+	// Default describes the value used when the field is empty. It is
+	// empty when there is no default.
+	Default string `json:"default,omitempty"`
+	// Validation are the rules the field value must satisfy.
+	Validation []string `json:"validation,omitempty"`
+	// Required is true when the field must be set.
+	Required bool `json:"required"`
+	// Since is the library version the field was added in.
+	Since string `json:"since"`
+}
+
+// ConfigReference returns the reference of all the ApplicationConfig fields
+// in declaration order. It is built from the default, validate and since
+// struct tags of the fields. The reference is built once and cached, see
+// ResetCaches.
+func ConfigReference() []FieldReference {
+	return getConfigReference()
+}
+
+func newConfigReference() []FieldReference {
+	t := reflect.TypeOf(ApplicationConfig{})
+
+	var refs []FieldReference
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		ref := FieldReference{
+			Name:    f.Name,
+			Type:    f.Type.String(),
+			Default: f.Tag.Get("default"),
+			Since:   f.Tag.Get("since"),
+		}
+		if v := f.Tag.Get("validate"); v != "" {
+			for _, rule := range strings.Split(v, ", ") {
+				if rule == "required" {
+					ref.Required = true
+				}
+				ref.Validation = append(ref.Validation, rule)
+			}
+		}
+
+		refs = append(refs, ref)
+	}
+
+	return refs
+}
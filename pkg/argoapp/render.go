@@ -0,0 +1,48 @@
+package argoapp
+
+import (
+	"encoding/json"
+
+	"github.com/giantswarm/microerror"
+	"sigs.k8s.io/yaml"
+)
+
+// RenderJSON generates the Application CR for the given config like
+// NewApplication and returns its indented JSON manifest. Object keys are
+// sorted so the output is stable, e.g. for GitOps commits and snapshot
+// tests.
+func RenderJSON(config ApplicationConfig) ([]byte, error) {
+	obj, err := NewApplication(config)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	// json.Marshal sorts the map keys.
+	data, err := json.MarshalIndent(obj.Object, "", "  ")
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return append(data, '\n'), nil
+}
+
+// RenderYAML is like RenderJSON but returns the YAML manifest.
+func RenderYAML(config ApplicationConfig) ([]byte, error) {
+	obj, err := NewApplication(config)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	// The key order of the JSON document is preserved.
+	data, err = yaml.JSONToYAML(data)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return data, nil
+}
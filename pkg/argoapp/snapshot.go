@@ -0,0 +1,183 @@
+package argoapp
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// snapshotPageSize is the number of Applications listed per request by
+// SnapshotFleet.
+const snapshotPageSize = 500
+
+// ApplicationLister is the subset of
+// k8s.io/client-go/dynamic.ResourceInterface needed to list Applications. It
+// is satisfied by argoappclient.ResourceInterface.
+type ApplicationLister interface {
+	List(ctx context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error)
+}
+
+// FleetSnapshot is the state of the Applications at a point in time. It can
+// be serialized to JSON to be compared with later snapshots.
+type FleetSnapshot struct {
+	TakenAt time.Time `json:"takenAt"`
+	// Applications are keyed by "<namespace>/<name>".
+	Applications map[string]SnapshotEntry `json:"applications"`
+}
+
+// SnapshotEntry is the state of an Application in a FleetSnapshot.
+type SnapshotEntry struct {
+	Namespace  string `json:"namespace"`
+	Name       string `json:"name"`
+	AppName    string `json:"appName,omitempty"`
+	AppVersion string `json:"appVersion,omitempty"`
+	AppCatalog string `json:"appCatalog,omitempty"`
+	ConfigRef  string `json:"configRef,omitempty"`
+	Sync       string `json:"sync,omitempty"`
+	Health     string `json:"health,omitempty"`
+}
+
+// ChangeType is the kind of a FleetChange.
+type ChangeType string
+
+const (
+	ChangeAdded   ChangeType = "Added"
+	ChangeRemoved ChangeType = "Removed"
+	// ChangeVersion is a change of the app version.
+	ChangeVersion ChangeType = "Version"
+	// ChangeConfigRef is a change of the config repository revision.
+	ChangeConfigRef ChangeType = "ConfigRef"
+	// ChangeSync is a sync status transition, e.g. from "Synced" to
+	// "OutOfSync".
+	ChangeSync ChangeType = "Sync"
+	// ChangeHealth is a health status transition, e.g. from "Healthy" to
+	// "Degraded".
+	ChangeHealth ChangeType = "Health"
+)
+
+// FleetChange is a change of an Application between two FleetSnapshots.
+type FleetChange struct {
+	Type      ChangeType `json:"type"`
+	Namespace string     `json:"namespace"`
+	Name      string     `json:"name"`
+	// From and To are the values before and after the change. They are
+	// empty for ChangeAdded and ChangeRemoved.
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+}
+
+// SnapshotFleet lists all the Applications and returns their FleetSnapshot,
+// e.g. to be compared with CompareSnapshots for daily installation reports.
+func SnapshotFleet(ctx context.Context, applications ApplicationLister) (*FleetSnapshot, error) {
+	s := &FleetSnapshot{
+		TakenAt:      time.Now().UTC(),
+		Applications: map[string]SnapshotEntry{},
+	}
+
+	opts := metav1.ListOptions{Limit: snapshotPageSize}
+	for {
+		list, err := applications.List(ctx, opts)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, app := range list.Items {
+			e := newSnapshotEntry(&app)
+			s.Applications[e.Namespace+"/"+e.Name] = e
+		}
+
+		opts.Continue = list.GetContinue()
+		if opts.Continue == "" {
+			break
+		}
+	}
+
+	return s, nil
+}
+
+// CompareSnapshots returns the changes of the Applications from snapshot a to
+// the later snapshot b, ordered by Application namespace and name.
+func CompareSnapshots(a, b *FleetSnapshot) []FleetChange {
+	keys := map[string]bool{}
+	for k := range a.Applications {
+		keys[k] = true
+	}
+	for k := range b.Applications {
+		keys[k] = true
+	}
+	var sorted []string
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var changes []FleetChange
+	for _, k := range sorted {
+		before, inA := a.Applications[k]
+		after, inB := b.Applications[k]
+
+		switch {
+		case !inA:
+			changes = append(changes, FleetChange{Type: ChangeAdded, Namespace: after.Namespace, Name: after.Name})
+			continue
+		case !inB:
+			changes = append(changes, FleetChange{Type: ChangeRemoved, Namespace: before.Namespace, Name: before.Name})
+			continue
+		}
+
+		for _, c := range []FleetChange{
+			{Type: ChangeVersion, From: before.AppVersion, To: after.AppVersion},
+			{Type: ChangeConfigRef, From: before.ConfigRef, To: after.ConfigRef},
+			{Type: ChangeSync, From: before.Sync, To: after.Sync},
+			{Type: ChangeHealth, From: before.Health, To: after.Health},
+		} {
+			if c.From == c.To {
+				continue
+			}
+			c.Namespace = after.Namespace
+			c.Name = after.Name
+			changes = append(changes, c)
+		}
+	}
+
+	return changes
+}
+
+func newSnapshotEntry(app *unstructured.Unstructured) SnapshotEntry {
+	env := pluginEnv(app)
+
+	e := SnapshotEntry{
+		Namespace:  app.GetNamespace(),
+		Name:       app.GetName(),
+		AppName:    env[pluginEnvAppName],
+		AppVersion: env[pluginEnvAppVersion],
+		AppCatalog: env[pluginEnvAppCatalog],
+	}
+	e.ConfigRef, _, _ = unstructured.NestedString(app.Object, "spec", "source", "targetRevision")
+	e.Sync, _, _ = unstructured.NestedString(app.Object, "status", "sync", "status")
+	e.Health, _, _ = unstructured.NestedString(app.Object, "status", "health", "status")
+
+	return e
+}
+
+// pluginEnv returns the config management plugin env of the Application.
+func pluginEnv(app *unstructured.Unstructured) map[string]string {
+	env := map[string]string{}
+
+	items, _, _ := unstructured.NestedSlice(app.Object, "spec", "source", "plugin", "env")
+	for _, item := range items {
+		e, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := e["name"].(string)
+		value, _ := e["value"].(string)
+		env[name] = value
+	}
+
+	return env
+}
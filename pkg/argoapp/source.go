@@ -0,0 +1,128 @@
+package argoapp
+
+import (
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// sourceApplicationConfig holds the fields shared by the constructors of
+// Applications not using the konfigure plugin. configType is the public config
+// type used in error messages.
+type sourceApplicationConfig struct {
+	configType string
+
+	name                 string
+	argoNamespace        string
+	argoProject          string
+	destinationNamespace string
+	destinationServer    string
+	destinationName      string
+	syncPolicyPreset     string
+}
+
+func (c sourceApplicationConfig) validate() error {
+	if c.name == "" {
+		return microerror.Maskf(invalidConfigError, "%s.Name must not be empty", c.configType)
+	}
+	if c.argoNamespace != "" {
+		if errs := validation.IsDNS1123Label(c.argoNamespace); len(errs) > 0 {
+			return microerror.Maskf(invalidConfigError, "%s.ArgoNamespace %#q is invalid: %s", c.configType, c.argoNamespace, strings.Join(errs, ", "))
+		}
+	}
+	if c.destinationNamespace == "" {
+		return microerror.Maskf(invalidConfigError, "%s.DestinationNamespace must not be empty", c.configType)
+	}
+	if c.destinationServer != "" && c.destinationName != "" {
+		return microerror.Maskf(invalidConfigError, "only one of %s.DestinationServer and %s.DestinationName can be set", c.configType, c.configType)
+	}
+
+	return nil
+}
+
+// newSourceApplication returns the Application with the given source. The
+// metadata, project, destination and sync policy are set the same way as for
+// the konfigure Applications.
+func newSourceApplication(c sourceApplicationConfig, source map[string]interface{}) (*unstructured.Unstructured, error) {
+	err := c.validate()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	policy, err := getSyncPolicy(c.syncPolicyPreset)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	defaults := GetDefaults()
+	if c.argoNamespace == "" {
+		c.argoNamespace = defaults.ArgoNamespace
+	}
+	if c.argoProject == "" {
+		c.argoProject = defaults.Project
+	}
+
+	destination := map[string]interface{}{
+		"namespace": c.destinationNamespace,
+	}
+	switch {
+	case c.destinationName != "":
+		destination["name"] = c.destinationName
+	case c.destinationServer != "":
+		destination["server"] = c.destinationServer
+	default:
+		destination["server"] = inClusterServer
+	}
+
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": argoAPIVersion,
+			"kind":       argoApplicationKind,
+			"metadata": map[string]interface{}{
+				"name":      c.name,
+				"namespace": c.argoNamespace,
+				"labels": map[string]interface{}{
+					ManagedByLabel: ManagedByLabelValue,
+				},
+				"finalizers": []interface{}{
+					argoResourceFinalizer,
+				},
+			},
+			"spec": map[string]interface{}{
+				"project":     c.argoProject,
+				"source":      source,
+				"destination": destination,
+				"syncPolicy":  policy.toUnstructured(),
+			},
+		},
+	}
+
+	err = SealIntegrity(obj)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	RemoveEmptyFields(obj)
+
+	return obj, nil
+}
+
+func toInterfaceSlice(s []string) []interface{} {
+	var r []interface{}
+	for _, v := range s {
+		r = append(r, v)
+	}
+
+	return r
+}
+
+func toInterfaceMap(m map[string]string) map[string]interface{} {
+	r := map[string]interface{}{}
+	for k, v := range m {
+		r[k] = v
+	}
+
+	return r
+}
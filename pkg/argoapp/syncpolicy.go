@@ -0,0 +1,231 @@
+package argoapp
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// PresetProduction syncs automatically, prunes resources no longer
+	// defined, reverts manual changes and retries failed syncs.
+	PresetProduction = "production"
+	// PresetStaging syncs automatically but never prunes resources.
+	PresetStaging = "staging"
+	// PresetManual disables automated syncing.
+	PresetManual = "manual"
+)
+
+var (
+	// defaultSyncPolicy is used when ApplicationConfig.SyncPolicyPreset is
+	// empty.
+	defaultSyncPolicy = SyncPolicy{
+		Automated: &SyncPolicyAutomated{
+			Prune:      true,
+			SelfHeal:   true,
+			AllowEmpty: false,
+		},
+	}
+
+	syncPolicyPresetsMutex sync.RWMutex
+	syncPolicyPresets      = map[string]SyncPolicy{
+		PresetProduction: {
+			Automated: &SyncPolicyAutomated{
+				Prune:    true,
+				SelfHeal: true,
+			},
+			Retry: &RetryStrategy{
+				Limit: 5,
+				Backoff: &Backoff{
+					Duration:    "5s",
+					Factor:      2,
+					MaxDuration: "3m",
+				},
+			},
+		},
+		PresetStaging: {
+			Automated: &SyncPolicyAutomated{
+				SelfHeal: true,
+			},
+		},
+		PresetManual: {},
+	}
+)
+
+// knownSyncOptions maps the Argo CD sync options to their allowed values. See
+// https://argo-cd.readthedocs.io/en/stable/user-guide/sync-options/.
+var knownSyncOptions = map[string][]string{
+	"ApplyOutOfSyncOnly":       {"true", "false"},
+	"CreateNamespace":          {"true", "false"},
+	"FailOnSharedResource":     {"true", "false"},
+	"PruneLast":                {"true", "false"},
+	"PrunePropagationPolicy":   {"foreground", "background", "orphan"},
+	"Replace":                  {"true", "false"},
+	"RespectIgnoreDifferences": {"true", "false"},
+	"ServerSideApply":          {"true", "false"},
+	"Validate":                 {"true", "false"},
+}
+
+// SyncPolicy is the Argo CD Application sync policy.
+type SyncPolicy struct {
+	// Automated enables automated syncing. The Application has to be synced
+	// manually when it is nil.
+	Automated *SyncPolicyAutomated
+	// Retry configures retrying of failed syncs.
+	Retry *RetryStrategy
+}
+
+type SyncPolicyAutomated struct {
+	// Prune deletes resources which are no longer defined in the source.
+	Prune bool
+	// SelfHeal reverts changes made to the live resources.
+	SelfHeal bool
+	// AllowEmpty allows deleting all application resources during
+	// automatic syncing.
+	AllowEmpty bool
+}
+
+type RetryStrategy struct {
+	// Limit is the maximum number of attempts for retrying a failed sync.
+	// Failed syncs are retried indefinitely when it is negative.
+	Limit int64
+	// Backoff controls how to back off on subsequent retries of failed
+	// syncs.
+	Backoff *Backoff
+}
+
+type Backoff struct {
+	// Duration is the amount to back off, e.g. "5s", "2m".
+	Duration string
+	// Factor is a factor to multiply the base duration after each failed
+	// retry.
+	Factor int64
+	// MaxDuration is the maximum amount of time allowed for the backoff
+	// strategy, e.g. "3m".
+	MaxDuration string
+}
+
+// RegisterSyncPolicyPreset registers a named SyncPolicy which can be selected
+// with ApplicationConfig.SyncPolicyPreset. Registering a name which is
+// already registered is an error. It is safe for concurrent use.
+func RegisterSyncPolicyPreset(name string, policy SyncPolicy) error {
+	if name == "" {
+		return microerror.Maskf(invalidConfigError, "preset name must not be empty")
+	}
+
+	syncPolicyPresetsMutex.Lock()
+	defer syncPolicyPresetsMutex.Unlock()
+
+	if _, ok := syncPolicyPresets[name]; ok {
+		return microerror.Maskf(invalidConfigError, "sync policy preset %#q is already registered", name)
+	}
+
+	syncPolicyPresets[name] = policy
+
+	return nil
+}
+
+// getSyncPolicy returns the SyncPolicy for the preset name. Empty name
+// returns the default SyncPolicy.
+func getSyncPolicy(preset string) (SyncPolicy, error) {
+	if preset == "" {
+		return defaultSyncPolicy, nil
+	}
+
+	syncPolicyPresetsMutex.RLock()
+	defer syncPolicyPresetsMutex.RUnlock()
+
+	policy, ok := syncPolicyPresets[preset]
+	if !ok {
+		return SyncPolicy{}, microerror.Maskf(invalidConfigError, "sync policy preset %#q is not registered", preset)
+	}
+
+	return policy, nil
+}
+
+// syncOptionProblem describes why o is not a known Argo CD sync option in
+// the Key=value format. It returns nil for valid options.
+func syncOptionProblem(o string) error {
+	split := strings.SplitN(o, "=", 2)
+	if len(split) != 2 {
+		return newProblem(ErrUnsupportedOption, "sync option %#q must be in the Key=value format", o)
+	}
+
+	values, ok := knownSyncOptions[split[0]]
+	if !ok {
+		return newProblem(ErrUnsupportedOption, "sync option %#q is unknown", split[0])
+	}
+
+	for _, v := range values {
+		if split[1] == v {
+			return nil
+		}
+	}
+
+	return newProblem(ErrUnsupportedOption, "sync option %#q value %#q is invalid, allowed values are %v", split[0], split[1], values)
+}
+
+func (p SyncPolicy) toUnstructured() map[string]interface{} {
+	m := map[string]interface{}{}
+
+	if p.Automated != nil {
+		m["automated"] = map[string]interface{}{
+			"prune":      p.Automated.Prune,
+			"selfHeal":   p.Automated.SelfHeal,
+			"allowEmpty": p.Automated.AllowEmpty,
+		}
+	}
+
+	if p.Retry != nil {
+		m["retry"] = p.Retry.toUnstructured()
+	}
+
+	return m
+}
+
+// problems describes why the RetryStrategy is invalid.
+func (s RetryStrategy) problems() []error {
+	if s.Backoff == nil {
+		return nil
+	}
+
+	var problems []error
+	for _, f := range []struct{ name, value string }{{"Duration", s.Backoff.Duration}, {"MaxDuration", s.Backoff.MaxDuration}} {
+		if f.value == "" {
+			continue
+		}
+		_, err := time.ParseDuration(f.value)
+		if err != nil {
+			problems = append(problems, newProblem(ErrInvalidValue, "%T.%s %#q is not a valid duration", *s.Backoff, f.name, f.value))
+		}
+	}
+	if s.Backoff.Factor < 0 {
+		problems = append(problems, newProblem(ErrInvalidValue, "%T.Factor must not be negative", *s.Backoff))
+	}
+
+	return problems
+}
+
+func (s RetryStrategy) toUnstructured() map[string]interface{} {
+	retry := map[string]interface{}{
+		"limit": s.Limit,
+	}
+
+	if s.Backoff != nil {
+		backoff := map[string]interface{}{}
+		if s.Backoff.Duration != "" {
+			backoff["duration"] = s.Backoff.Duration
+		}
+		if s.Backoff.Factor != 0 {
+			backoff["factor"] = s.Backoff.Factor
+		}
+		if s.Backoff.MaxDuration != "" {
+			backoff["maxDuration"] = s.Backoff.MaxDuration
+		}
+		retry["backoff"] = backoff
+	}
+
+	return retry
+}
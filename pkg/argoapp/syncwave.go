@@ -0,0 +1,39 @@
+package argoapp
+
+const (
+	// SyncWaveAnnotation orders the sync of the Applications managed by a
+	// parent Application (app of apps). Lower waves are synced first.
+	SyncWaveAnnotation = "argocd.argoproj.io/sync-wave"
+	// HookAnnotation makes the Application a resource hook of its parent
+	// Application. See HookType.
+	HookAnnotation = "argocd.argoproj.io/hook"
+)
+
+// HookType is the Argo CD resource hook type of an Application.
+type HookType string
+
+const (
+	HookTypePreSync  HookType = "PreSync"
+	HookTypeSync     HookType = "Sync"
+	HookTypePostSync HookType = "PostSync"
+	HookTypeSyncFail HookType = "SyncFail"
+	HookTypeSkip     HookType = "Skip"
+)
+
+var hookTypes = []HookType{
+	HookTypePreSync,
+	HookTypeSync,
+	HookTypePostSync,
+	HookTypeSyncFail,
+	HookTypeSkip,
+}
+
+func isValidHookType(t HookType) bool {
+	for _, h := range hookTypes {
+		if t == h {
+			return true
+		}
+	}
+
+	return false
+}
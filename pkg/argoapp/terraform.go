@@ -0,0 +1,165 @@
+package argoapp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// terraformResourceType is the Terraform kubernetes provider resource the
+// Applications are rendered as.
+const terraformResourceType = "kubernetes_manifest"
+
+// RenderTerraform generates the Application CRs of the configs like
+// NewApplicationCollection and returns them as Terraform (or OpenTofu)
+// kubernetes_manifest resources in the HCL native syntax. The resources are
+// named after the Applications and object keys are sorted so the output is
+// stable.
+func RenderTerraform(configs []ApplicationConfig) ([]byte, error) {
+	apps, err := NewApplicationCollection(configs)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var b bytes.Buffer
+	for i, app := range apps {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "resource %q %q {\n  manifest = ", terraformResourceType, terraformResourceName(app))
+		writeHCLValue(&b, app.Object, "  ")
+		b.WriteString("\n}\n")
+	}
+
+	return b.Bytes(), nil
+}
+
+// RenderTerraformJSON is like RenderTerraform but returns the resources in
+// the Terraform JSON syntax, e.g. to be written to a .tf.json file.
+func RenderTerraformJSON(configs []ApplicationConfig) ([]byte, error) {
+	apps, err := NewApplicationCollection(configs)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	resources := map[string]interface{}{}
+	for _, app := range apps {
+		resources[terraformResourceName(app)] = map[string]interface{}{
+			"manifest": escapeTerraformTemplates(app.Object),
+		}
+	}
+	doc := map[string]interface{}{
+		"resource": map[string]interface{}{
+			terraformResourceType: resources,
+		},
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return append(data, '\n'), nil
+}
+
+// terraformResourceName returns the Application name as a Terraform
+// identifier. Identifiers can not contain dots or start with a digit.
+func terraformResourceName(app *unstructured.Unstructured) string {
+	name := strings.ReplaceAll(app.GetName(), ".", "_")
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "app_" + name
+	}
+
+	return name
+}
+
+// escapeTerraformTemplates returns a copy of v with the template sequences
+// of the strings escaped, so Terraform does not interpolate them.
+func escapeTerraformTemplates(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := map[string]interface{}{}
+		for k, e := range v {
+			m[escapeTerraformString(k)] = escapeTerraformTemplates(e)
+		}
+		return m
+	case []interface{}:
+		var l []interface{}
+		for _, e := range v {
+			l = append(l, escapeTerraformTemplates(e))
+		}
+		return l
+	case string:
+		return escapeTerraformString(v)
+	default:
+		return v
+	}
+}
+
+func escapeTerraformString(s string) string {
+	s = strings.ReplaceAll(s, "${", "$${")
+	s = strings.ReplaceAll(s, "%{", "%%{")
+
+	return s
+}
+
+func writeHCLValue(b *bytes.Buffer, v interface{}, indent string) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			b.WriteString("{}")
+			return
+		}
+		var keys []string
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		b.WriteString("{\n")
+		for _, k := range keys {
+			b.WriteString(indent + "  ")
+			writeHCLString(b, k)
+			b.WriteString(" = ")
+			writeHCLValue(b, v[k], indent+"  ")
+			b.WriteString("\n")
+		}
+		b.WriteString(indent + "}")
+	case []interface{}:
+		if len(v) == 0 {
+			b.WriteString("[]")
+			return
+		}
+		b.WriteString("[\n")
+		for _, e := range v {
+			b.WriteString(indent + "  ")
+			writeHCLValue(b, e, indent+"  ")
+			b.WriteString(",\n")
+		}
+		b.WriteString(indent + "]")
+	case string:
+		writeHCLString(b, v)
+	case nil:
+		b.WriteString("null")
+	default:
+		// Booleans and numbers have the same syntax as in JSON.
+		data, _ := json.Marshal(v)
+		b.Write(data)
+	}
+}
+
+// writeHCLString writes the quoted string. The HCL escape sequences are a
+// subset of the JSON ones, plus the escaped template sequences.
+func writeHCLString(b *bytes.Buffer, s string) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(escapeTerraformString(s))
+
+	b.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+}
@@ -0,0 +1,51 @@
+package argoapp
+
+import (
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// TTLAnnotation holds the ApplicationConfig.TTL as a Go duration, e.g. "72h".
+// The Application expires when the TTL elapsed since its creation
+// timestamp, so regenerating and applying it does not extend its lifetime.
+const TTLAnnotation = "argoapp.giantswarm.io/ttl"
+
+// ExpiresAtAnnotation holds the RFC 3339 time after which the Application can
+// be deleted. It is not set by the generator but takes precedence over the
+// TTLAnnotation, e.g. to extend the lifetime of a single Application.
+const ExpiresAtAnnotation = "argoapp.giantswarm.io/expires-at"
+
+// IsExpired returns true when the object has the ExpiresAtAnnotation set to
+// a time before now or, without it, when the TTLAnnotation elapsed since its
+// creation timestamp before now. Objects which were not created yet never
+// expire.
+func IsExpired(obj *unstructured.Unstructured, now time.Time) (bool, error) {
+	annotations := obj.GetAnnotations()
+
+	if v, ok := annotations[ExpiresAtAnnotation]; ok {
+		expiresAt, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return false, microerror.Maskf(invalidConfigError, "annotation %#q value %#q is invalid: %s", ExpiresAtAnnotation, v, err)
+		}
+
+		return expiresAt.Before(now), nil
+	}
+
+	v, ok := annotations[TTLAnnotation]
+	if !ok {
+		return false, nil
+	}
+	ttl, err := time.ParseDuration(v)
+	if err != nil {
+		return false, microerror.Maskf(invalidConfigError, "annotation %#q value %#q is invalid: %s", TTLAnnotation, v, err)
+	}
+
+	created := obj.GetCreationTimestamp()
+	if created.IsZero() {
+		return false, nil
+	}
+
+	return created.Add(ttl).Before(now), nil
+}
@@ -0,0 +1,76 @@
+package argoapp
+
+import (
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// UpdateApplicationConfig returns a copy of the existing Application with the
+// fields compared by ConfigDiff updated: the plugin env, the source
+// targetRevision and path, the ManifestGeneratePathsAnnotation and the
+// destination. All the other fields, including the ones added by users or
+// other controllers, are preserved. The targetRevision of an Application
+// pinned with PinConfigRef is kept and ConfigRef is recorded to be restored
+// by UnpinConfigRef instead.
+func UpdateApplicationConfig(existing *unstructured.Unstructured, config ApplicationConfig) (*unstructured.Unstructured, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if existing.GetName() != config.Name {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Name %#q does not match Application %#q", config, config.Name, existing.GetName())
+	}
+
+	obj := existing.DeepCopy()
+
+	if IsPinned(obj) {
+		annotations := obj.GetAnnotations()
+		annotations[PinnedFromAnnotation] = config.ConfigRef
+		obj.SetAnnotations(annotations)
+	} else {
+		err = unstructured.SetNestedField(obj.Object, config.ConfigRef, "spec", "source", "targetRevision")
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	sourcePath := config.SourcePath
+	if sourcePath == "" {
+		sourcePath = "."
+	} else {
+		annotations := obj.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[ManifestGeneratePathsAnnotation] = "."
+		obj.SetAnnotations(annotations)
+	}
+	err = unstructured.SetNestedField(obj.Object, sourcePath, "spec", "source", "path")
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	err = unstructured.SetNestedSlice(obj.Object, newPluginEnv(config), "spec", "source", "plugin", "env")
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	destination := map[string]interface{}{}
+	if config.AppDestinationNamespace != "" {
+		destination["namespace"] = config.AppDestinationNamespace
+	}
+	switch {
+	case config.AppDestinationName != "":
+		destination["name"] = config.AppDestinationName
+	case config.AppDestinationServer != "":
+		destination["server"] = config.AppDestinationServer
+	default:
+		destination["server"] = inClusterServer
+	}
+	err = unstructured.SetNestedMap(obj.Object, destination, "spec", "destination")
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return obj, nil
+}
@@ -0,0 +1,157 @@
+package argoapp
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// Validate returns a *ValidationError listing all the problems of the
+// config, so they can be fixed at once. It is matched by IsInvalidConfig.
+func (config ApplicationConfig) Validate() error {
+	problems := config.problems()
+	if len(problems) > 0 {
+		return microerror.Mask(&ValidationError{Problems: problems})
+	}
+
+	return nil
+}
+
+// problems describes why the config is invalid.
+func (config ApplicationConfig) problems() []error {
+	var problems []error
+	add := func(reason error, format string, args ...interface{}) {
+		problems = append(problems, newProblem(reason, format, args...))
+	}
+	missing := func(field string) {
+		problems = append(problems, &MissingFieldError{Field: field})
+	}
+
+	if config.Name == "" {
+		missing("Name")
+	} else if errs := validation.IsDNS1123Subdomain(config.Name); len(errs) > 0 {
+		add(ErrInvalidName, "%T.Name %#q is invalid: %s", config, config.Name, strings.Join(errs, ", "))
+	} else if len(config.Name) > MaxNameLength {
+		add(ErrInvalidName, "%T.Name %#q must be no more than %d characters, it can be shortened with GenerateName", config, config.Name, MaxNameLength)
+	}
+	if config.ArgoNamespace != "" {
+		if errs := validation.IsDNS1123Label(config.ArgoNamespace); len(errs) > 0 {
+			add(ErrInvalidName, "%T.ArgoNamespace %#q is invalid: %s", config, config.ArgoNamespace, strings.Join(errs, ", "))
+		}
+	}
+	if config.AppName == "" {
+		missing("AppName")
+	}
+	if config.AppVersion == "" {
+		missing("AppVersion")
+	} else if _, err := version.ParseSemantic(config.AppVersion); err != nil {
+		add(ErrInvalidValue, "%T.AppVersion %#q is not a semantic version", config, config.AppVersion)
+	}
+	if config.AppCatalog == "" {
+		missing("AppCatalog")
+	}
+	if config.MultiNamespace {
+		if config.AppDestinationNamespace != "" {
+			add(ErrInvalidValue, "%T.AppDestinationNamespace must be empty with %T.MultiNamespace", config, config)
+		}
+		if len(config.OwnershipLabels) > 0 {
+			add(ErrInvalidValue, "%T.OwnershipLabels can not be set with %T.MultiNamespace", config, config)
+		}
+	} else if config.AppDestinationNamespace == "" {
+		missing("AppDestinationNamespace")
+	} else if errs := validation.IsDNS1123Label(config.AppDestinationNamespace); len(errs) > 0 {
+		add(ErrInvalidName, "%T.AppDestinationNamespace %#q is invalid: %s", config, config.AppDestinationNamespace, strings.Join(errs, ", "))
+	}
+	if config.AppDestinationServer != "" && config.AppDestinationName != "" {
+		add(ErrInvalidValue, "only one of %T.AppDestinationServer and %T.AppDestinationName can be set", config, config)
+	}
+	if config.ConfigRef == "" {
+		missing("ConfigRef")
+	} else if !isValidGitRef(config.ConfigRef) {
+		add(ErrInvalidValue, "%T.ConfigRef %#q is not a valid git ref", config, config.ConfigRef)
+	}
+	if config.SourcePath != "" {
+		if path.IsAbs(config.SourcePath) || path.Clean(config.SourcePath) != config.SourcePath || config.SourcePath == ".." || strings.HasPrefix(config.SourcePath, "../") {
+			add(ErrInvalidValue, "%T.SourcePath %#q must be a clean path relative to the repository root", config, config.SourcePath)
+		}
+	}
+	for name := range config.ExtraPluginEnv {
+		if errs := validation.IsEnvVarName(name); len(errs) > 0 {
+			add(ErrInvalidName, "%T.ExtraPluginEnv name %#q is invalid: %s", config, name, strings.Join(errs, ", "))
+		}
+		if name == pluginEnvAppName || name == pluginEnvAppVersion || name == pluginEnvAppCatalog || name == pluginEnvAppDisableForceUpgrade {
+			add(ErrInvalidValue, "%T.ExtraPluginEnv must not set %#q", config, name)
+		}
+	}
+	if config.SyncPolicyPreset != "" {
+		if _, err := getSyncPolicy(config.SyncPolicyPreset); err != nil {
+			add(ErrUnsupportedOption, "%T.SyncPolicyPreset %#q is not registered", config, config.SyncPolicyPreset)
+		}
+	}
+	if config.Retry != nil {
+		problems = append(problems, config.Retry.problems()...)
+	}
+	for _, o := range config.SyncOptions {
+		if p := syncOptionProblem(o); p != nil {
+			problems = append(problems, p)
+		}
+	}
+	for k, v := range config.OwnershipLabels {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			add(ErrInvalidValue, "%T.OwnershipLabels key %#q is invalid: %s", config, k, strings.Join(errs, ", "))
+		}
+		if errs := validation.IsValidLabelValue(v); len(errs) > 0 {
+			add(ErrInvalidValue, "%T.OwnershipLabels[%#q] value %#q is invalid: %s", config, k, v, strings.Join(errs, ", "))
+		}
+	}
+	if config.HookType != "" && !isValidHookType(config.HookType) {
+		add(ErrUnsupportedOption, "%T.HookType %#q is not one of %v", config, config.HookType, hookTypes)
+	}
+	for i, n := range config.Notifications {
+		problems = append(problems, n.problems(i)...)
+	}
+	if config.RevisionHistoryLimit != nil && *config.RevisionHistoryLimit < 0 {
+		add(ErrInvalidValue, "%T.RevisionHistoryLimit must not be negative", config)
+	}
+	infoNames := map[string]bool{}
+	for i, e := range config.Info {
+		if e.Name == "" {
+			missing(fmt.Sprintf("Info[%d].Name", i))
+		} else if infoNames[e.Name] {
+			add(ErrInvalidValue, "%T.Info[%d].Name %#q is not unique", config, i, e.Name)
+		}
+		infoNames[e.Name] = true
+		if e.Value == "" {
+			missing(fmt.Sprintf("Info[%d].Value", i))
+		}
+	}
+	if config.TTL < 0 {
+		add(ErrInvalidValue, "%T.TTL must not be negative", config)
+	}
+
+	return problems
+}
+
+// isValidGitRef implements a subset of the git check-ref-format rules for
+// branch and tag names and commit SHAs.
+func isValidGitRef(ref string) bool {
+	if ref == "@" || strings.HasPrefix(ref, "-") || strings.HasPrefix(ref, "/") || strings.HasSuffix(ref, "/") || strings.HasSuffix(ref, ".") || strings.HasSuffix(ref, ".lock") {
+		return false
+	}
+	for _, s := range []string{"..", "@{", "//", "/."} {
+		if strings.Contains(ref, s) {
+			return false
+		}
+	}
+	for _, r := range ref {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(" ~^:?*[\\", r) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,9 @@
+package argoapp
+
+// Verifier verifies the provenance of the app, e.g. the signature of the
+// chart, before the Application CR is generated. See Settings.Verifier.
+type Verifier interface {
+	// Verify returns an error when the app referenced by the config
+	// (AppName, AppVersion, AppCatalog) can't be verified.
+	Verify(config ApplicationConfig) error
+}
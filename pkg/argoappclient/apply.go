@@ -0,0 +1,133 @@
+package argoappclient
+
+import (
+	"context"
+
+	"github.com/giantswarm/microerror"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// DefaultFieldManager is the server-side apply field manager used when
+// ApplyOptions.FieldManager is empty.
+const DefaultFieldManager = "argoapp"
+
+type ApplyOptions struct {
+	// FieldManager is the server-side apply field manager. Defaults to
+	// DefaultFieldManager.
+	FieldManager string
+	// Force takes ownership of the fields managed by other field managers
+	// instead of failing with a conflict.
+	Force bool
+	// OperationStrategy is how an Application with a running operation is
+	// handled. Defaults to OperationStrategyIgnore.
+	OperationStrategy OperationStrategy
+	// OperationWait configures waiting for the running operation to finish
+	// with OperationStrategyWait and OperationStrategyTerminate.
+	OperationWait WaitOptions
+	// DestinationChecker is optional. When set, the Application is not
+	// applied unless its destination cluster is ready.
+	DestinationChecker *DestinationChecker
+	// NameCollisionStrategy is how an existing Application with the same
+	// name not managed by this library is handled. Defaults to
+	// NameCollisionStrategyIgnore. The Application returned with
+	// NameCollisionStrategySuffixHash may have a different name than obj.
+	NameCollisionStrategy NameCollisionStrategy
+	// IgnoreFreeze applies the Application even when it is frozen with the
+	// argoapp.FrozenAnnotation.
+	IgnoreFreeze bool
+}
+
+// ApplyApplication creates or updates the Application with server-side apply
+// using DefaultFieldManager. It returns the Application as stored by the API
+// server.
+func ApplyApplication(ctx context.Context, applications ResourceInterface, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	applied, err := ApplyApplicationWithOptions(ctx, applications, obj, ApplyOptions{})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return applied, nil
+}
+
+// ApplyApplicationWithOptions is like ApplyApplication but allows to configure
+// the field manager and conflict handling. Unless ApplyOptions.IgnoreFreeze is
+// set, an existing Application frozen with the argoapp.FrozenAnnotation is
+// left untouched and an error matched by IsFrozen is returned. An existing
+// Application pinned with argoapp.PinConfigRef keeps its pinned
+// targetRevision, the targetRevision of obj is recorded in the
+// argoapp.PinnedFromAnnotation instead.
+func ApplyApplicationWithOptions(ctx context.Context, applications ResourceInterface, obj *unstructured.Unstructured, options ApplyOptions) (*unstructured.Unstructured, error) {
+	if obj.GetName() == "" {
+		return nil, microerror.Maskf(invalidConfigError, "Application name must not be empty")
+	}
+	if options.FieldManager == "" {
+		options.FieldManager = DefaultFieldManager
+	}
+
+	if options.DestinationChecker != nil {
+		err := options.DestinationChecker.Check(ctx, obj)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	obj = obj.DeepCopy()
+	unstructured.RemoveNestedField(obj.Object, "metadata", "managedFields")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "resourceVersion")
+	unstructured.RemoveNestedField(obj.Object, "status")
+
+	obj, err := resolveNameCollision(ctx, applications, obj, options.NameCollisionStrategy)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if !options.IgnoreFreeze {
+		err = checkFrozen(ctx, applications, obj.GetName())
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	if options.OperationStrategy == OperationStrategyQueue {
+		queued, err := queueIfOperationInProgress(ctx, applications, obj)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		} else if queued {
+			return nil, microerror.Maskf(changesQueuedError, "Application %#q", obj.GetName())
+		}
+	} else {
+		err := handleRunningOperation(ctx, applications, obj.GetName(), options.OperationStrategy, options.OperationWait)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	configRef, pinned, err := keepPin(ctx, applications, obj)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	applied, err := applications.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{
+		FieldManager: options.FieldManager,
+		Force:        &options.Force,
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if pinned {
+		applied, err = recordPinnedFrom(ctx, applications, applied, configRef, options.FieldManager)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	return applied, nil
+}
@@ -0,0 +1,107 @@
+package argoappclient
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/giantswarm/argoapp/pkg/argoapp"
+)
+
+const testCommit = "0123456789abcdef0123456789abcdef01234567"
+
+func Test_ApplyApplication_Pinned(t *testing.T) {
+	testCases := []struct {
+		name                   string
+		pinned                 bool
+		expectedTargetRevision string
+		expectedPinnedFrom     string
+	}{
+		{
+			name:                   "case 0: unpinned Application is updated",
+			pinned:                 false,
+			expectedTargetRevision: "v2",
+			expectedPinnedFrom:     "",
+		},
+		{
+			name:                   "case 1: pinned Application keeps the pinned commit",
+			pinned:                 true,
+			expectedTargetRevision: testCommit,
+			expectedPinnedFrom:     "v2",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			live, err := argoapp.NewApplication(newTestApplicationConfig("v1"))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if tc.pinned {
+				live, err = argoapp.PinConfigRef(live, testCommit, argoapp.PinOptions{Reason: "incident"})
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+			}
+			applications := newFakeResource(live)
+
+			desired, err := argoapp.NewApplication(newTestApplicationConfig("v2"))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			applied, err := ApplyApplication(context.Background(), applications, desired)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			targetRevision, _, _ := unstructured.NestedString(applied.Object, "spec", "source", "targetRevision")
+			if targetRevision != tc.expectedTargetRevision {
+				t.Fatalf("expected targetRevision %#q, got %#q", tc.expectedTargetRevision, targetRevision)
+			}
+			annotations := applied.GetAnnotations()
+			if annotations[argoapp.PinnedFromAnnotation] != tc.expectedPinnedFrom {
+				t.Fatalf("expected annotation %#q to be %#q, got %#q", argoapp.PinnedFromAnnotation, tc.expectedPinnedFrom, annotations[argoapp.PinnedFromAnnotation])
+			}
+			if tc.pinned && annotations[argoapp.PinReasonAnnotation] != "incident" {
+				t.Fatalf("expected annotation %#q to be preserved, got %#q", argoapp.PinReasonAnnotation, annotations[argoapp.PinReasonAnnotation])
+			}
+
+			// The applied object must not take over the pin, so it can not
+			// conflict with the field manager of the pin.
+			for _, p := range applications.patches {
+				if p.patchType != types.ApplyPatchType {
+					continue
+				}
+				obj := &unstructured.Unstructured{}
+				err = obj.UnmarshalJSON(p.data)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				targetRevision, _, _ := unstructured.NestedString(obj.Object, "spec", "source", "targetRevision")
+				if targetRevision != tc.expectedTargetRevision {
+					t.Fatalf("expected applied targetRevision %#q, got %#q", tc.expectedTargetRevision, targetRevision)
+				}
+				if _, ok := obj.GetAnnotations()[argoapp.PinnedFromAnnotation]; ok {
+					t.Fatalf("expected applied object without annotation %#q", argoapp.PinnedFromAnnotation)
+				}
+			}
+		})
+	}
+}
+
+func newTestApplicationConfig(configRef string) argoapp.ApplicationConfig {
+	return argoapp.ApplicationConfig{
+		Name:                    "hello-world",
+		AppName:                 "hello-world",
+		AppVersion:              "1.2.3",
+		AppCatalog:              "giantswarm",
+		AppDestinationNamespace: "hello-world",
+		ConfigRef:               configRef,
+	}
+}
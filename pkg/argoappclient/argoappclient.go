@@ -0,0 +1,67 @@
+// Package argoappclient provides helpers for managing Argo CD Application CRs
+// generated with package argoapp in a cluster.
+//
+// The helpers accept the ResourceInterface subset of
+// k8s.io/client-go/dynamic.ResourceInterface so this package only depends on
+// k8s.io/apimachinery. A client for Applications in the argocd namespace can
+// be created with:
+//
+//	dynamicClient.Resource(argoappclient.ApplicationResource).Namespace("argocd")
+package argoappclient
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+var (
+	// ApplicationResource is the Argo CD Application resource.
+	ApplicationResource = schema.GroupVersionResource{
+		Group:    "argoproj.io",
+		Version:  "v1alpha1",
+		Resource: "applications",
+	}
+	// AppProjectResource is the Argo CD AppProject resource.
+	AppProjectResource = schema.GroupVersionResource{
+		Group:    "argoproj.io",
+		Version:  "v1alpha1",
+		Resource: "appprojects",
+	}
+)
+
+// ResourceInterface is the subset of
+// k8s.io/client-go/dynamic.ResourceInterface used by this package. It is
+// expected to be scoped to a single resource and namespace.
+type ResourceInterface interface {
+	Create(ctx context.Context, obj *unstructured.Unstructured, options metav1.CreateOptions, subresources ...string) (*unstructured.Unstructured, error)
+	Update(ctx context.Context, obj *unstructured.Unstructured, options metav1.UpdateOptions, subresources ...string) (*unstructured.Unstructured, error)
+	Delete(ctx context.Context, name string, options metav1.DeleteOptions, subresources ...string) error
+	Get(ctx context.Context, name string, options metav1.GetOptions, subresources ...string) (*unstructured.Unstructured, error)
+	List(ctx context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, options metav1.PatchOptions, subresources ...string) (*unstructured.Unstructured, error)
+}
+
+// stripServerFields removes the fields set by the API server and Argo CD
+// controllers so the object can be created in another cluster.
+func stripServerFields(obj *unstructured.Unstructured) {
+	for _, field := range []string{
+		"creationTimestamp",
+		"generation",
+		"managedFields",
+		"ownerReferences",
+		"resourceVersion",
+		"selfLink",
+		"uid",
+	} {
+		unstructured.RemoveNestedField(obj.Object, "metadata", field)
+	}
+
+	unstructured.RemoveNestedField(obj.Object, "operation")
+	unstructured.RemoveNestedField(obj.Object, "status")
+}
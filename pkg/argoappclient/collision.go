@@ -0,0 +1,111 @@
+package argoappclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/giantswarm/argoapp/pkg/argoapp"
+)
+
+// NameCollisionStrategy is how ApplyApplicationWithOptions handles an
+// existing Application with the same name which is not managed by this
+// library, i.e. does not have the argoapp.ManagedByLabel label.
+type NameCollisionStrategy string
+
+const (
+	// NameCollisionStrategyIgnore applies the Application over the existing
+	// one.
+	NameCollisionStrategyIgnore NameCollisionStrategy = ""
+	// NameCollisionStrategyError fails with an error matched by
+	// IsNameCollision.
+	NameCollisionStrategyError NameCollisionStrategy = "Error"
+	// NameCollisionStrategySuffixHash applies the Application with the name
+	// suffixed with a hash of its name and destination. The suffix is stable
+	// so subsequent applies update the same Application.
+	NameCollisionStrategySuffixHash NameCollisionStrategy = "SuffixHash"
+	// NameCollisionStrategyAdoptIfIdentical takes over the existing
+	// Application when its spec is identical and fails with an error matched
+	// by IsNameCollision otherwise.
+	NameCollisionStrategyAdoptIfIdentical NameCollisionStrategy = "AdoptIfIdentical"
+)
+
+const nameHashLength = 8
+
+// resolveNameCollision returns obj, renamed with
+// NameCollisionStrategySuffixHash when its name is taken by an Application
+// not managed by this library.
+func resolveNameCollision(ctx context.Context, applications ResourceInterface, obj *unstructured.Unstructured, strategy NameCollisionStrategy) (*unstructured.Unstructured, error) {
+	switch strategy {
+	case NameCollisionStrategyIgnore:
+		return obj, nil
+	case NameCollisionStrategyError, NameCollisionStrategySuffixHash, NameCollisionStrategyAdoptIfIdentical:
+	default:
+		return nil, microerror.Maskf(invalidConfigError, "unknown name collision strategy %#q", strategy)
+	}
+
+	existing, collides, err := nameCollision(ctx, applications, obj.GetName())
+	if err != nil {
+		return nil, microerror.Mask(err)
+	} else if !collides {
+		return obj, nil
+	}
+
+	switch strategy {
+	case NameCollisionStrategySuffixHash:
+		obj.SetName(suffixedName(obj))
+
+		_, collides, err := nameCollision(ctx, applications, obj.GetName())
+		if err != nil {
+			return nil, microerror.Mask(err)
+		} else if collides {
+			return nil, microerror.Maskf(nameCollisionError, "Application %#q already exists and is not managed by %#q", obj.GetName(), argoapp.ManagedByLabelValue)
+		}
+
+		return obj, nil
+	case NameCollisionStrategyAdoptIfIdentical:
+		if equality.Semantic.DeepEqual(existing.Object["spec"], obj.Object["spec"]) {
+			return obj, nil
+		}
+
+		return nil, microerror.Maskf(nameCollisionError, "Application %#q already exists with a different spec and is not managed by %#q", obj.GetName(), argoapp.ManagedByLabelValue)
+	default:
+		return nil, microerror.Maskf(nameCollisionError, "Application %#q already exists and is not managed by %#q", obj.GetName(), argoapp.ManagedByLabelValue)
+	}
+}
+
+// nameCollision returns the existing Application and true when it is not
+// managed by this library.
+func nameCollision(ctx context.Context, applications ResourceInterface, name string) (*unstructured.Unstructured, bool, error) {
+	existing, err := applications.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, microerror.Mask(err)
+	}
+
+	return existing, existing.GetLabels()[argoapp.ManagedByLabel] != argoapp.ManagedByLabelValue, nil
+}
+
+func suffixedName(obj *unstructured.Unstructured) string {
+	server, _, _ := unstructured.NestedString(obj.Object, "spec", "destination", "server")
+	name, _, _ := unstructured.NestedString(obj.Object, "spec", "destination", "name")
+	namespace, _, _ := unstructured.NestedString(obj.Object, "spec", "destination", "namespace")
+
+	sum := sha256.Sum256([]byte(strings.Join([]string{obj.GetName(), server, name, namespace}, "/")))
+	suffix := "-" + hex.EncodeToString(sum[:])[:nameHashLength]
+
+	base := obj.GetName()
+	if max := argoapp.MaxNameLength - len(suffix); len(base) > max {
+		base = strings.TrimRight(base[:max], "-.")
+	}
+
+	return base + suffix
+}
@@ -0,0 +1,55 @@
+package argoappclient
+
+import (
+	"context"
+
+	"github.com/giantswarm/microerror"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/argoapp/pkg/argoapp"
+)
+
+type DeleteOptions struct {
+	// OperationStrategy is how an Application with a running operation is
+	// handled. Defaults to OperationStrategyIgnore.
+	OperationStrategy OperationStrategy
+	// OperationWait configures waiting for the running operation to finish
+	// with OperationStrategyWait and OperationStrategyTerminate.
+	OperationWait WaitOptions
+	// Force deletes the Application even when it is protected with the
+	// argoapp.ProtectedAnnotation.
+	Force bool
+}
+
+// DeleteApplication deletes the Application with the given name. Deleting a
+// missing Application is not an error. It returns an error matched by
+// IsProtected when the Application is protected with the
+// argoapp.ProtectedAnnotation, unless DeleteOptions.Force is set.
+func DeleteApplication(ctx context.Context, applications ResourceInterface, name string, options DeleteOptions) error {
+	if !options.Force {
+		app, err := applications.Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+		if argoapp.IsProtected(app) {
+			return microerror.Mask(newProtectedError("Application", []string{name}))
+		}
+	}
+
+	err := handleRunningOperation(ctx, applications, name, options.OperationStrategy, options.OperationWait)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = applications.Delete(ctx, name, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package argoappclient
+
+import (
+	"context"
+	"encoding/base64"
+
+	"github.com/giantswarm/microerror"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	clusterSecretTypeLabel = "argocd.argoproj.io/secret-type"
+	inClusterName          = "in-cluster"
+	inClusterServer        = "https://kubernetes.default.svc"
+)
+
+// SecretResource is the core/v1 Secret resource. Argo CD cluster secrets are
+// read with a client created with:
+//
+//	dynamicClient.Resource(argoappclient.SecretResource).Namespace("argocd")
+var SecretResource = schema.GroupVersionResource{
+	Version:  "v1",
+	Resource: "secrets",
+}
+
+// ClusterConnectionChecker returns the Argo CD connection state of the
+// cluster, e.g. by calling the Argo CD API GET /api/v1/clusters/{server}
+// returning connectionState.status.
+type ClusterConnectionChecker interface {
+	// IsConnected returns true when Argo CD can connect to the cluster
+	// with the given server URL. The message describes the failure.
+	IsConnected(ctx context.Context, server string) (bool, string, error)
+}
+
+type DestinationCheckerConfig struct {
+	// Secrets is scoped to the namespace Argo CD is installed in.
+	Secrets ResourceInterface
+	// Connection is optional. Only the cluster secret existence is checked
+	// when it is nil.
+	Connection ClusterConnectionChecker
+}
+
+// DestinationChecker checks the destination cluster of an Application is
+// registered in Argo CD and connected, so reconcilers can requeue instead of
+// creating Applications which can not sync.
+type DestinationChecker struct {
+	secrets    ResourceInterface
+	connection ClusterConnectionChecker
+}
+
+func NewDestinationChecker(config DestinationCheckerConfig) (*DestinationChecker, error) {
+	if config.Secrets == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Secrets must not be empty", config)
+	}
+
+	c := &DestinationChecker{
+		secrets:    config.Secrets,
+		connection: config.Connection,
+	}
+
+	return c, nil
+}
+
+// Check returns an error matched by IsDestinationNotReady when the
+// destination cluster of the Application is not registered or not
+// connected. The in-cluster destination is always ready.
+func (c *DestinationChecker) Check(ctx context.Context, app *unstructured.Unstructured) error {
+	server, _, _ := unstructured.NestedString(app.Object, "spec", "destination", "server")
+	name, _, _ := unstructured.NestedString(app.Object, "spec", "destination", "name")
+	if name == inClusterName || name == "" && (server == "" || server == inClusterServer) {
+		return nil
+	}
+
+	list, err := c.secrets.List(ctx, metav1.ListOptions{LabelSelector: clusterSecretTypeLabel + "=cluster"})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	found := false
+	for _, secret := range list.Items {
+		secretServer := secretData(secret, "server")
+		if (name != "" && secretData(secret, "name") == name) || (name == "" && secretServer == server) {
+			server = secretServer
+			found = true
+			break
+		}
+	}
+	if !found {
+		return microerror.Maskf(destinationNotReadyError, "cluster %#q of Application %#q is not registered in Argo CD", destination(name, server), app.GetName())
+	}
+
+	if c.connection == nil {
+		return nil
+	}
+
+	connected, message, err := c.connection.IsConnected(ctx, server)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if !connected {
+		return microerror.Maskf(destinationNotReadyError, "cluster %#q of Application %#q is not connected: %s", destination(name, server), app.GetName(), message)
+	}
+
+	return nil
+}
+
+// secretData returns the decoded value of the Secret data key.
+func secretData(secret unstructured.Unstructured, key string) string {
+	v, _, _ := unstructured.NestedString(secret.Object, "data", key)
+	b, err := base64.StdEncoding.DecodeString(v)
+	if err != nil {
+		return ""
+	}
+
+	return string(b)
+}
+
+func destination(name, server string) string {
+	if name != "" {
+		return name
+	}
+
+	return server
+}
@@ -0,0 +1,68 @@
+package argoappclient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ValidateAgainstCluster creates or updates the Application with a
+// server-side dry-run apply using DefaultFieldManager, so the CRD schema
+// validation and the admission webhooks run without persisting it. It
+// returns an error matched by IsRejected listing the reported problems when
+// the API server rejects the Application, e.g. in CI before the real apply.
+func ValidateAgainstCluster(ctx context.Context, applications ResourceInterface, obj *unstructured.Unstructured) error {
+	if obj.GetName() == "" {
+		return microerror.Maskf(invalidConfigError, "Application name must not be empty")
+	}
+
+	obj = obj.DeepCopy()
+	unstructured.RemoveNestedField(obj.Object, "metadata", "managedFields")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "resourceVersion")
+	unstructured.RemoveNestedField(obj.Object, "status")
+
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	force := true
+	_, err = applications.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{
+		DryRun:       []string{metav1.DryRunAll},
+		FieldManager: DefaultFieldManager,
+		Force:        &force,
+	})
+	if apierrors.IsInvalid(err) || apierrors.IsBadRequest(err) || apierrors.IsForbidden(err) {
+		return microerror.Maskf(rejectedError, "Application %#q: %s", obj.GetName(), rejectionMessage(err))
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// rejectionMessage returns the message of the API server error including the
+// causes, e.g. the invalid fields.
+func rejectionMessage(err error) string {
+	status, ok := err.(apierrors.APIStatus)
+	if !ok || status.Status().Details == nil || len(status.Status().Details.Causes) == 0 {
+		return err.Error()
+	}
+
+	var causes []string
+	for _, c := range status.Status().Details.Causes {
+		if c.Field != "" {
+			causes = append(causes, fmt.Sprintf("%s: %s", c.Field, c.Message))
+		} else {
+			causes = append(causes, c.Message)
+		}
+	}
+
+	return strings.Join(causes, "; ")
+}
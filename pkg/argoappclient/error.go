@@ -0,0 +1,120 @@
+package argoappclient
+
+import "github.com/giantswarm/microerror"
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var checkFailedError = &microerror.Error{
+	Kind: "checkFailedError",
+}
+
+// IsCheckFailed asserts checkFailedError.
+func IsCheckFailed(err error) bool {
+	return microerror.Cause(err) == checkFailedError
+}
+
+var verificationFailedError = &microerror.Error{
+	Kind: "verificationFailedError",
+}
+
+// IsVerificationFailed asserts verificationFailedError.
+func IsVerificationFailed(err error) bool {
+	return microerror.Cause(err) == verificationFailedError
+}
+
+var operationRunningError = &microerror.Error{
+	Kind: "operationRunningError",
+}
+
+// IsOperationRunning asserts operationRunningError.
+func IsOperationRunning(err error) bool {
+	return microerror.Cause(err) == operationRunningError
+}
+
+var changesQueuedError = &microerror.Error{
+	Kind: "changesQueuedError",
+}
+
+// IsChangesQueued asserts changesQueuedError.
+func IsChangesQueued(err error) bool {
+	return microerror.Cause(err) == changesQueuedError
+}
+
+var destinationNotReadyError = &microerror.Error{
+	Kind: "destinationNotReadyError",
+}
+
+// IsDestinationNotReady asserts destinationNotReadyError.
+func IsDestinationNotReady(err error) bool {
+	return microerror.Cause(err) == destinationNotReadyError
+}
+
+var nameCollisionError = &microerror.Error{
+	Kind: "nameCollisionError",
+}
+
+// IsNameCollision asserts nameCollisionError.
+func IsNameCollision(err error) bool {
+	return microerror.Cause(err) == nameCollisionError
+}
+
+var executionFailedError = &microerror.Error{
+	Kind: "executionFailedError",
+}
+
+// IsExecutionFailed asserts executionFailedError.
+func IsExecutionFailed(err error) bool {
+	return microerror.Cause(err) == executionFailedError
+}
+
+var leaderElectionLostError = &microerror.Error{
+	Kind: "leaderElectionLostError",
+}
+
+// IsLeaderElectionLost asserts leaderElectionLostError.
+func IsLeaderElectionLost(err error) bool {
+	return microerror.Cause(err) == leaderElectionLostError
+}
+
+var rejectedError = &microerror.Error{
+	Kind: "rejectedError",
+}
+
+// IsRejected asserts rejectedError.
+func IsRejected(err error) bool {
+	return microerror.Cause(err) == rejectedError
+}
+
+var protectedError = &microerror.Error{
+	Kind: "protectedError",
+}
+
+// IsProtected asserts protectedError.
+func IsProtected(err error) bool {
+	return microerror.Cause(err) == protectedError
+}
+
+var autoSyncEnabledError = &microerror.Error{
+	Kind: "autoSyncEnabledError",
+}
+
+// IsAutoSyncEnabled asserts autoSyncEnabledError.
+func IsAutoSyncEnabled(err error) bool {
+	return microerror.Cause(err) == autoSyncEnabledError
+}
+
+var frozenError = &microerror.Error{
+	Kind: "frozenError",
+}
+
+// IsFrozen asserts frozenError.
+func IsFrozen(err error) bool {
+	return microerror.Cause(err) == frozenError
+}
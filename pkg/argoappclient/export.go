@@ -0,0 +1,129 @@
+package argoappclient
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/giantswarm/microerror"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// ConflictStrategy decides what RestoreFleet does when an object from the
+// archive already exists in the cluster.
+type ConflictStrategy string
+
+const (
+	// ConflictStrategyFail stops the restore and returns the error.
+	ConflictStrategyFail ConflictStrategy = "fail"
+	// ConflictStrategySkip leaves the existing object untouched.
+	ConflictStrategySkip ConflictStrategy = "skip"
+	// ConflictStrategyOverwrite replaces the existing object with the one
+	// from the archive.
+	ConflictStrategyOverwrite ConflictStrategy = "overwrite"
+)
+
+// ExportFleet writes the AppProjects and Applications matching the selector
+// to w. Status and fields set by the API server are stripped. The archive is
+// a JSON encoded v1 List which can be restored with RestoreFleet (or kubectl).
+func ExportFleet(ctx context.Context, applications, appProjects ResourceInterface, selector labels.Selector, w io.Writer) error {
+	archive := &unstructured.UnstructuredList{}
+	archive.SetAPIVersion("v1")
+	archive.SetKind("List")
+
+	// AppProjects go first so they exist when the Applications referencing
+	// them are restored.
+	for _, resource := range []ResourceInterface{appProjects, applications} {
+		list, err := resource.List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, item := range list.Items {
+			stripServerFields(&item)
+			archive.Items = append(archive.Items, item)
+		}
+	}
+
+	data, err := archive.MarshalJSON()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	_, err = w.Write(data)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// RestoreFleet creates the objects from an archive written by ExportFleet.
+// Objects which already exist are handled according to the strategy.
+func RestoreFleet(ctx context.Context, applications, appProjects ResourceInterface, r io.Reader, strategy ConflictStrategy) error {
+	switch strategy {
+	case ConflictStrategyFail, ConflictStrategySkip, ConflictStrategyOverwrite:
+	default:
+		return microerror.Maskf(invalidConfigError, "unknown conflict strategy %#q", strategy)
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var archive unstructured.UnstructuredList
+	err = json.Unmarshal(data, &archive)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, item := range archive.Items {
+		obj := item.DeepCopy()
+		stripServerFields(obj)
+
+		var resource ResourceInterface
+		switch obj.GetKind() {
+		case "Application":
+			resource = applications
+		case "AppProject":
+			resource = appProjects
+		default:
+			return microerror.Maskf(invalidConfigError, "archive contains unsupported kind %#q", obj.GetKind())
+		}
+
+		_, err = resource.Create(ctx, obj, metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			switch strategy {
+			case ConflictStrategySkip:
+				continue
+			case ConflictStrategyOverwrite:
+				err = overwrite(ctx, resource, obj)
+			}
+		}
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func overwrite(ctx context.Context, resource ResourceInterface, obj *unstructured.Unstructured) error {
+	current, err := resource.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	obj.SetResourceVersion(current.GetResourceVersion())
+
+	_, err = resource.Update(ctx, obj, metav1.UpdateOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package argoappclient
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// fakeResource is an in-memory ResourceInterface. Apply and merge patches
+// are both applied as JSON merge patches, field ownership is not tracked.
+type fakeResource struct {
+	mu      sync.Mutex
+	objects map[string]*unstructured.Unstructured
+	// patches records the patches in the order they were received.
+	patches []fakePatch
+	// update is optional. When set, it is called instead of storing the
+	// object in Update.
+	update func(ctx context.Context, obj *unstructured.Unstructured) (*unstructured.Unstructured, error)
+}
+
+type fakePatch struct {
+	patchType types.PatchType
+	data      []byte
+}
+
+func newFakeResource(objects ...*unstructured.Unstructured) *fakeResource {
+	r := &fakeResource{
+		objects: map[string]*unstructured.Unstructured{},
+	}
+	for _, obj := range objects {
+		r.objects[obj.GetName()] = obj.DeepCopy()
+	}
+
+	return r
+}
+
+func (r *fakeResource) Create(ctx context.Context, obj *unstructured.Unstructured, options metav1.CreateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.objects[obj.GetName()]; ok {
+		return nil, apierrors.NewAlreadyExists(fakeGroupResource, obj.GetName())
+	}
+	r.objects[obj.GetName()] = obj.DeepCopy()
+
+	return obj.DeepCopy(), nil
+}
+
+func (r *fakeResource) Update(ctx context.Context, obj *unstructured.Unstructured, options metav1.UpdateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	r.mu.Lock()
+	update := r.update
+	r.mu.Unlock()
+	if update != nil {
+		return update(ctx, obj)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.objects[obj.GetName()]; !ok {
+		return nil, apierrors.NewNotFound(fakeGroupResource, obj.GetName())
+	}
+	r.objects[obj.GetName()] = obj.DeepCopy()
+
+	return obj.DeepCopy(), nil
+}
+
+func (r *fakeResource) Delete(ctx context.Context, name string, options metav1.DeleteOptions, subresources ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.objects[name]; !ok {
+		return apierrors.NewNotFound(fakeGroupResource, name)
+	}
+	delete(r.objects, name)
+
+	return nil
+}
+
+func (r *fakeResource) Get(ctx context.Context, name string, options metav1.GetOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	obj, ok := r.objects[name]
+	if !ok {
+		return nil, apierrors.NewNotFound(fakeGroupResource, name)
+	}
+
+	return obj.DeepCopy(), nil
+}
+
+func (r *fakeResource) List(ctx context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	list := &unstructured.UnstructuredList{}
+	for _, obj := range r.objects {
+		list.Items = append(list.Items, *obj.DeepCopy())
+	}
+
+	return list, nil
+}
+
+func (r *fakeResource) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	return nil, apierrors.NewMethodNotSupported(fakeGroupResource, "watch")
+}
+
+func (r *fakeResource) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, options metav1.PatchOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.patches = append(r.patches, fakePatch{patchType: pt, data: data})
+
+	var patch map[string]interface{}
+	err := json.Unmarshal(data, &patch)
+	if err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+
+	obj, ok := r.objects[name]
+	if !ok {
+		if pt != types.ApplyPatchType {
+			return nil, apierrors.NewNotFound(fakeGroupResource, name)
+		}
+		obj = &unstructured.Unstructured{Object: map[string]interface{}{}}
+	}
+	obj = obj.DeepCopy()
+	mergeObject(obj.Object, patch)
+	r.objects[name] = obj
+
+	return obj.DeepCopy(), nil
+}
+
+var fakeGroupResource = schema.GroupResource{Group: "test", Resource: "tests"}
+
+// mergeObject merges src into dst like a JSON merge patch.
+func mergeObject(dst, src map[string]interface{}) {
+	for k, v := range src {
+		if v == nil {
+			delete(dst, k)
+			continue
+		}
+		srcMap, ok := v.(map[string]interface{})
+		if !ok {
+			dst[k] = v
+			continue
+		}
+		dstMap, ok := dst[k].(map[string]interface{})
+		if !ok {
+			dstMap = map[string]interface{}{}
+			dst[k] = dstMap
+		}
+		mergeObject(dstMap, srcMap)
+	}
+}
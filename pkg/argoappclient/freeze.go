@@ -0,0 +1,223 @@
+package argoappclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/giantswarm/argoapp/pkg/argoapp"
+)
+
+// freezeWindowsAnnotation holds the sync windows managed by
+// ApplyFreezeCalendar so they can be told apart from the windows configured
+// by other means.
+const freezeWindowsAnnotation = "argoapp.giantswarm.io/freeze-windows"
+
+const defaultFreezeCalendarResync = time.Hour
+
+// FreezeCalendar is a list of change freezes.
+type FreezeCalendar struct {
+	Freezes []Freeze `json:"freezes"`
+}
+
+// Freeze denies syncing of the Applications between Start and End.
+type Freeze struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// Applications is a list of Application name glob patterns the freeze
+	// applies to. Defaults to all Applications.
+	Applications []string `json:"applications,omitempty"`
+	// ManualSync allows manual syncs during the freeze.
+	ManualSync bool `json:"manualSync,omitempty"`
+}
+
+// ApplyFreezeCalendar sets deny sync windows for all the freezes from the
+// calendar which have not ended at now on the AppProject with the given name.
+// Windows of ended freezes are removed. Sync windows not managed by this
+// function are left untouched. Applying an empty calendar clears all the
+// managed windows.
+//
+// Sync window schedules are cron expressions without a year, so a window
+// recurs every year on the date of its freeze until it is removed. Use
+// RunFreezeCalendar, or apply the calendar again after each freeze ended, to
+// remove the windows of ended freezes.
+func ApplyFreezeCalendar(ctx context.Context, appProjects ResourceInterface, project string, calendar FreezeCalendar, now time.Time) error {
+	var windows []interface{}
+	for _, f := range calendar.Freezes {
+		if !f.End.After(f.Start) {
+			return microerror.Maskf(invalidConfigError, "freeze starting at %s must end after it starts", f.Start)
+		}
+		if !f.End.After(now) {
+			continue
+		}
+
+		windows = append(windows, newDenySyncWindow(f))
+	}
+
+	obj, err := appProjects.Get(ctx, project, metav1.GetOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	existing, _, err := unstructured.NestedSlice(obj.Object, "spec", "syncWindows")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var managed []interface{}
+	if v, ok := obj.GetAnnotations()[freezeWindowsAnnotation]; ok {
+		err = json.Unmarshal([]byte(v), &managed)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	var syncWindows []interface{}
+	for _, w := range existing {
+		if !containsWindow(managed, w) {
+			syncWindows = append(syncWindows, w)
+		}
+	}
+	syncWindows = append(syncWindows, windows...)
+
+	if len(syncWindows) == 0 {
+		unstructured.RemoveNestedField(obj.Object, "spec", "syncWindows")
+	} else {
+		err = unstructured.SetNestedSlice(obj.Object, syncWindows, "spec", "syncWindows")
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	if len(windows) == 0 {
+		delete(annotations, freezeWindowsAnnotation)
+	} else {
+		data, err := json.Marshal(windows)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		annotations[freezeWindowsAnnotation] = string(data)
+	}
+	obj.SetAnnotations(annotations)
+
+	_, err = appProjects.Update(ctx, obj, metav1.UpdateOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+type FreezeCalendarOptions struct {
+	// Resync is the maximum interval between applying the calendar, e.g. to
+	// restore windows removed manually. Defaults to 1 hour.
+	Resync time.Duration
+	// OnError is optional. It is called with the error of each failed
+	// ApplyFreezeCalendar and the calendar is applied again after Resync.
+	OnError func(err error)
+}
+
+// RunFreezeCalendar applies the calendar with ApplyFreezeCalendar until ctx
+// is cancelled. It is applied again when a freeze ends, so the window of the
+// freeze is removed before it recurs, and at least every resync interval.
+// Run it with RunWithLeaderElection to run multiple replicas.
+func RunFreezeCalendar(ctx context.Context, appProjects ResourceInterface, project string, calendar FreezeCalendar, options FreezeCalendarOptions) error {
+	if options.Resync < 0 {
+		return microerror.Maskf(invalidConfigError, "%T.Resync must not be negative", options)
+	}
+	if options.Resync == 0 {
+		options.Resync = defaultFreezeCalendarResync
+	}
+
+	for {
+		now := time.Now()
+		err := ApplyFreezeCalendar(ctx, appProjects, project, calendar, now)
+		if IsInvalidConfig(err) {
+			return microerror.Mask(err)
+		} else if err != nil && options.OnError != nil && ctx.Err() == nil {
+			options.OnError(err)
+		}
+
+		next := options.Resync
+		if err == nil {
+			for _, f := range calendar.Freezes {
+				if d := f.End.Sub(now); d > 0 && d < next {
+					next = d
+				}
+			}
+		}
+
+		t := time.NewTimer(next)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil
+		case <-t.C:
+		}
+	}
+}
+
+func newDenySyncWindow(f Freeze) map[string]interface{} {
+	start := f.Start.UTC()
+
+	applications := []interface{}{"*"}
+	if len(f.Applications) > 0 {
+		applications = nil
+		for _, a := range f.Applications {
+			applications = append(applications, a)
+		}
+	}
+
+	return map[string]interface{}{
+		"kind":         "deny",
+		"schedule":     fmt.Sprintf("%d %d %d %d *", start.Minute(), start.Hour(), start.Day(), int(start.Month())),
+		"duration":     f.End.Sub(f.Start).String(),
+		"timeZone":     "UTC",
+		"applications": applications,
+		"manualSync":   f.ManualSync,
+	}
+}
+
+func containsWindow(windows []interface{}, w interface{}) bool {
+	data, err := json.Marshal(w)
+	if err != nil {
+		return false
+	}
+
+	for _, m := range windows {
+		mdata, err := json.Marshal(m)
+		if err == nil && string(mdata) == string(data) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkFrozen returns an error matched by IsFrozen with the reason of the
+// freeze when the Application with the given name has the
+// argoapp.FrozenAnnotation. A missing Application is not frozen.
+func checkFrozen(ctx context.Context, applications ResourceInterface, name string) error {
+	app, err := applications.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if argoapp.IsFrozen(app) {
+		return microerror.Maskf(frozenError, "skipped Application %#q frozen with the %#q annotation: %s", name, argoapp.FrozenAnnotation, app.GetAnnotations()[argoapp.FrozenAnnotation])
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package argoappclient
+
+import (
+	"context"
+
+	"github.com/giantswarm/microerror"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/giantswarm/argoapp/pkg/argoapp"
+)
+
+const defaultProject = "default"
+
+type GarbageCollectionOptions struct {
+	// DryRun only reports the orphaned objects without deleting them.
+	DryRun bool
+	// Force deletes the objects protected with the
+	// argoapp.ProtectedAnnotation too. They are skipped and reported with
+	// an error matched by IsProtected otherwise.
+	Force bool
+}
+
+// CollectOrphanedAppProjects deletes the AppProjects generated by package
+// argoapp (see argoapp.NewAppProject) which are not used by any Application.
+// It returns the names of the deleted AppProjects, or of the AppProjects
+// which would be deleted with GarbageCollectionOptions.DryRun.
+//
+// Applications of all the Argo CD instances the AppProjects are used by must
+// be listed by applications.
+func CollectOrphanedAppProjects(ctx context.Context, projects, applications ResourceInterface, options GarbageCollectionOptions) ([]string, error) {
+	apps, err := applications.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	used := map[string]bool{}
+	for _, app := range apps.Items {
+		project, _, _ := unstructured.NestedString(app.Object, "spec", "project")
+		if project == "" {
+			project = defaultProject
+		}
+		used[project] = true
+	}
+
+	collected, err := collectOrphans(ctx, projects, managedSelector(nil), options, func(project unstructured.Unstructured) bool {
+		return !used[project.GetName()]
+	})
+	if err != nil {
+		return collected, microerror.Mask(err)
+	}
+
+	return collected, nil
+}
+
+// CollectOrphanedClusterSecrets deletes the Argo CD cluster secrets labelled
+// with argoapp.ManagedByLabel which are not the destination of any
+// Application, e.g. because the cluster was removed from its collection. It
+// returns the names of the deleted Secrets, or of the Secrets which would be
+// deleted with GarbageCollectionOptions.DryRun.
+func CollectOrphanedClusterSecrets(ctx context.Context, secrets, applications ResourceInterface, options GarbageCollectionOptions) ([]string, error) {
+	apps, err := applications.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	usedNames := map[string]bool{}
+	usedServers := map[string]bool{}
+	for _, app := range apps.Items {
+		server, _, _ := unstructured.NestedString(app.Object, "spec", "destination", "server")
+		name, _, _ := unstructured.NestedString(app.Object, "spec", "destination", "name")
+		if name != "" {
+			usedNames[name] = true
+		} else {
+			usedServers[server] = true
+		}
+	}
+
+	selector := managedSelector(labels.Set{clusterSecretTypeLabel: "cluster"})
+	collected, err := collectOrphans(ctx, secrets, selector, options, func(secret unstructured.Unstructured) bool {
+		return !usedNames[secretData(secret, "name")] && !usedServers[secretData(secret, "server")]
+	})
+	if err != nil {
+		return collected, microerror.Mask(err)
+	}
+
+	return collected, nil
+}
+
+func managedSelector(set labels.Set) labels.Selector {
+	s := labels.Set{
+		argoapp.ManagedByLabel: argoapp.ManagedByLabelValue,
+	}
+	for k, v := range set {
+		s[k] = v
+	}
+
+	return labels.SelectorFromSet(s)
+}
+
+func collectOrphans(ctx context.Context, resources ResourceInterface, selector labels.Selector, options GarbageCollectionOptions, orphaned func(unstructured.Unstructured) bool) ([]string, error) {
+	list, err := resources.List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var collected, protected []string
+	for _, obj := range list.Items {
+		if !orphaned(obj) {
+			continue
+		}
+		if !options.Force && argoapp.IsProtected(&obj) {
+			protected = append(protected, obj.GetName())
+			continue
+		}
+
+		if !options.DryRun {
+			err = resources.Delete(ctx, obj.GetName(), metav1.DeleteOptions{})
+			if apierrors.IsNotFound(err) {
+				continue
+			} else if err != nil {
+				return collected, microerror.Mask(err)
+			}
+		}
+
+		collected = append(collected, obj.GetName())
+	}
+
+	err = newProtectedError("objects", protected)
+	if err != nil {
+		return collected, microerror.Mask(err)
+	}
+
+	return collected, nil
+}
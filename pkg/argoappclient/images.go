@@ -0,0 +1,106 @@
+package argoappclient
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// DeployedImagesAnnotation holds the JSON encoded list of DeployedImages of
+// the Application as evidence of what is running.
+const DeployedImagesAnnotation = "argoapp.giantswarm.io/deployed-images"
+
+// DeployedImage is a container image deployed by an Application.
+type DeployedImage struct {
+	Image  string `json:"image"`
+	Digest string `json:"digest"`
+}
+
+// DigestResolver resolves image references to their digests, e.g. by
+// querying the registry.
+type DigestResolver interface {
+	// Resolve returns the digest of the image, e.g. "sha256:...".
+	Resolve(ctx context.Context, image string) (string, error)
+}
+
+// RecordDeployedImages resolves the digests of the images listed in the
+// Application status.summary.images and sets them as the
+// DeployedImagesAnnotation. Images referenced by digest are not resolved. It
+// should be called once the Application is synced, e.g. as a post-sync Check
+// with DeployedImagesCheck.
+func RecordDeployedImages(ctx context.Context, applications ResourceInterface, app *unstructured.Unstructured, resolver DigestResolver) ([]DeployedImage, error) {
+	images, _, err := unstructured.NestedStringSlice(app.Object, "status", "summary", "images")
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	sort.Strings(images)
+
+	deployed := []DeployedImage{}
+	for _, image := range images {
+		digest := ""
+		if i := strings.Index(image, "@"); i >= 0 {
+			digest = image[i+1:]
+		} else {
+			digest, err = resolver.Resolve(ctx, image)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+		}
+
+		deployed = append(deployed, DeployedImage{Image: image, Digest: digest})
+	}
+
+	data, err := json.Marshal(deployed)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if app.GetAnnotations()[DeployedImagesAnnotation] == string(data) {
+		return deployed, nil
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				DeployedImagesAnnotation: string(data),
+			},
+		},
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	_, err = applications.Patch(ctx, app.GetName(), types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return deployed, nil
+}
+
+// DeployedImagesCheck is a Check recording the deployed images with
+// RecordDeployedImages, so they are recorded whenever the post-sync checks
+// run. It fails when the images can not be recorded.
+type DeployedImagesCheck struct {
+	Applications ResourceInterface
+	Resolver     DigestResolver
+}
+
+func (c DeployedImagesCheck) Name() string {
+	return "deployed images"
+}
+
+func (c DeployedImagesCheck) Check(ctx context.Context, app *unstructured.Unstructured) error {
+	_, err := RecordDeployedImages(ctx, c.Applications, app, c.Resolver)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
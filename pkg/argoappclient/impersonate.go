@@ -0,0 +1,92 @@
+package argoappclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	impersonateUserHeader        = "Impersonate-User"
+	impersonateUIDHeader         = "Impersonate-Uid"
+	impersonateGroupHeader       = "Impersonate-Group"
+	impersonateExtraHeaderPrefix = "Impersonate-Extra-"
+)
+
+// Impersonation is the user the API requests are made as, so access to
+// Applications is enforced with the Kubernetes RBAC of that user rather than
+// of the client's own credentials.
+type Impersonation struct {
+	UserName string
+	UID      string
+	Groups   []string
+	Extra    map[string][]string
+}
+
+type impersonationKey struct{}
+
+// WithImpersonation returns a context making the helpers of this package
+// impersonate the given user when their ResourceInterface transport is
+// wrapped with ImpersonatingRoundTripper.
+func WithImpersonation(ctx context.Context, impersonation Impersonation) context.Context {
+	return context.WithValue(ctx, impersonationKey{}, impersonation)
+}
+
+// ImpersonationFromContext returns the Impersonation set with
+// WithImpersonation.
+func ImpersonationFromContext(ctx context.Context) (Impersonation, bool) {
+	impersonation, ok := ctx.Value(impersonationKey{}).(Impersonation)
+	return impersonation, ok
+}
+
+// ImpersonatingRoundTripper wraps rt to set the impersonation headers of the
+// Impersonation from the request context. Requests without an Impersonation
+// in their context are sent unmodified. It is meant to be used with the
+// client-go rest.Config, so a single client can serve requests of many users:
+//
+//	restConfig.Wrap(argoappclient.ImpersonatingRoundTripper)
+//
+// The client's own credentials must be allowed to impersonate the users and
+// groups. It must not be combined with rest.Config.Impersonate which takes
+// precedence.
+func ImpersonatingRoundTripper(rt http.RoundTripper) http.RoundTripper {
+	return &impersonatingRoundTripper{rt: rt}
+}
+
+type impersonatingRoundTripper struct {
+	rt http.RoundTripper
+}
+
+func (t *impersonatingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	impersonation, ok := ImpersonationFromContext(req.Context())
+	if !ok || impersonation.UserName == "" {
+		return t.rt.RoundTrip(req)
+	}
+
+	// RoundTrip must not modify the request.
+	req = req.Clone(req.Context())
+
+	// Drop the impersonation headers set by wrappers further out so they
+	// are not merged with the ones from the context.
+	for k := range req.Header {
+		if k == impersonateUserHeader || k == impersonateUIDHeader || k == impersonateGroupHeader || strings.HasPrefix(k, impersonateExtraHeaderPrefix) {
+			req.Header.Del(k)
+		}
+	}
+
+	req.Header.Set(impersonateUserHeader, impersonation.UserName)
+	if impersonation.UID != "" {
+		req.Header.Set(impersonateUIDHeader, impersonation.UID)
+	}
+	for _, g := range impersonation.Groups {
+		req.Header.Add(impersonateGroupHeader, g)
+	}
+	for k, values := range impersonation.Extra {
+		for _, v := range values {
+			req.Header.Add(impersonateExtraHeaderPrefix+url.PathEscape(k), v)
+		}
+	}
+
+	return t.rt.RoundTrip(req)
+}
@@ -0,0 +1,75 @@
+package argoappclient
+
+import (
+	"context"
+
+	"github.com/giantswarm/microerror"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/argoapp/pkg/argoapp"
+)
+
+type IntegrityOptions struct {
+	// Repair restores the removed and changed labels and annotations. See
+	// argoapp.RepairIntegrity.
+	Repair bool
+}
+
+// IntegrityViolation lists the problems of an Application found by
+// CheckIntegrity.
+type IntegrityViolation struct {
+	Application string
+	Problems    []string
+	// Repaired is true when the Application was repaired.
+	Repaired bool
+}
+
+// CheckIntegrity returns the Applications whose labels or annotations set
+// when they were generated were manually removed or changed, as recorded in
+// the argoapp.IntegrityAnnotation. All Applications are checked, as the
+// argoapp.ManagedByLabel label may be removed too.
+func CheckIntegrity(ctx context.Context, applications ResourceInterface, options IntegrityOptions) ([]IntegrityViolation, error) {
+	list, err := applications.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var violations []IntegrityViolation
+	for _, app := range list.Items {
+		problems, err := argoapp.IntegrityProblems(&app)
+		if err != nil {
+			return violations, microerror.Mask(err)
+		}
+		if len(problems) == 0 {
+			continue
+		}
+
+		violation := IntegrityViolation{
+			Application: app.GetName(),
+			Problems:    problems,
+		}
+
+		if options.Repair {
+			obj, err := argoapp.RepairIntegrity(&app)
+			if err != nil {
+				return violations, microerror.Mask(err)
+			}
+
+			// The resourceVersion of the listed Application makes the
+			// update fail with a conflict instead of overwriting
+			// concurrent changes.
+			_, err = applications.Update(ctx, obj, metav1.UpdateOptions{})
+			if apierrors.IsNotFound(err) {
+				continue
+			} else if err != nil {
+				return violations, microerror.Mask(err)
+			}
+			violation.Repaired = true
+		}
+
+		violations = append(violations, violation)
+	}
+
+	return violations, nil
+}
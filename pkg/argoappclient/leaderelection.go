@@ -0,0 +1,267 @@
+package argoappclient
+
+import (
+	"context"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// LeaseResource is the Kubernetes Lease resource used for leader election.
+var LeaseResource = schema.GroupVersionResource{
+	Group:    "coordination.k8s.io",
+	Version:  "v1",
+	Resource: "leases",
+}
+
+const (
+	defaultLeaseDuration = 15 * time.Second
+	defaultRenewDeadline = 10 * time.Second
+	defaultRetryPeriod   = 2 * time.Second
+)
+
+type LeaderElectionOptions struct {
+	// LeaseName is the name of the Lease shared by the replicas.
+	LeaseName string
+	// Identity identifies the replica, e.g. the pod name.
+	Identity string
+	// LeaseDuration is how long the other replicas wait before taking over
+	// a Lease which is not renewed. It is rounded down to seconds and must
+	// be at least one second. Defaults to 15 seconds.
+	LeaseDuration time.Duration
+	// RenewDeadline is how long the leader retries renewing the Lease
+	// before it stops leading. It must be shorter than LeaseDuration.
+	// Defaults to 10 seconds.
+	RenewDeadline time.Duration
+	// RetryPeriod is the interval between the attempts to acquire and renew
+	// the Lease. Defaults to 2 seconds.
+	RetryPeriod time.Duration
+}
+
+// RunWithLeaderElection runs fn, e.g. RunSyncLoop or WebhookRelay, only
+// while this replica holds the Lease, so running multiple replicas does not
+// cause duplicate writes. The leases client must be scoped to LeaseResource
+// and a namespace.
+//
+// The context passed to fn is cancelled when ctx is cancelled or the Lease
+// can not be renewed within RenewDeadline, including when the renewal
+// requests hang. RunWithLeaderElection waits for fn to return before it
+// releases the Lease and returns, so fn can drain its in-flight work. When
+// the Lease is lost it returns an error matched by IsLeaderElectionLost,
+// otherwise the error of fn.
+//
+// While the Lease is held by another replica acquiring it is retried every
+// RetryPeriod. Errors which retrying can not fix, e.g. RBAC forbidding access
+// to the Lease or a missing namespace, are returned immediately, other
+// errors once they persisted for LeaseDuration.
+func RunWithLeaderElection(ctx context.Context, leases ResourceInterface, options LeaderElectionOptions, fn func(ctx context.Context) error) error {
+	if options.LeaseName == "" {
+		return microerror.Maskf(invalidConfigError, "%T.LeaseName must not be empty", options)
+	}
+	if options.Identity == "" {
+		return microerror.Maskf(invalidConfigError, "%T.Identity must not be empty", options)
+	}
+	if options.LeaseDuration == 0 {
+		options.LeaseDuration = defaultLeaseDuration
+	}
+	if options.RenewDeadline == 0 {
+		options.RenewDeadline = defaultRenewDeadline
+	}
+	if options.RetryPeriod == 0 {
+		options.RetryPeriod = defaultRetryPeriod
+	}
+	if options.LeaseDuration < time.Second {
+		return microerror.Maskf(invalidConfigError, "%T.LeaseDuration must be at least one second", options)
+	}
+	if options.RenewDeadline >= options.LeaseDuration {
+		return microerror.Maskf(invalidConfigError, "%T.RenewDeadline must be shorter than %T.LeaseDuration", options, options)
+	}
+	if options.RetryPeriod >= options.RenewDeadline {
+		return microerror.Maskf(invalidConfigError, "%T.RetryPeriod must be shorter than %T.RenewDeadline", options, options)
+	}
+
+	e := elector{leases: leases, options: options}
+
+	// Acquire the Lease.
+	var failingSince time.Time
+	for {
+		now := time.Now()
+		acquired, err := e.tryAcquireOrRenew(ctx, now)
+		if ctx.Err() != nil {
+			return nil
+		} else if err != nil && isPermanentLeaseError(err) {
+			return microerror.Mask(err)
+		} else if err != nil {
+			if failingSince.IsZero() {
+				failingSince = now
+			} else if now.Sub(failingSince) >= options.LeaseDuration {
+				return microerror.Mask(err)
+			}
+		} else if acquired {
+			break
+		} else {
+			failingSince = time.Time{}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(options.RetryPeriod):
+		}
+	}
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- fn(leaderCtx)
+	}()
+
+	// Renew the Lease while fn runs.
+	lost := false
+	renewed := time.Now()
+	ticker := time.NewTicker(options.RetryPeriod)
+	defer ticker.Stop()
+	for !lost {
+		select {
+		case err := <-done:
+			e.release()
+			return microerror.Mask(err)
+		case <-ctx.Done():
+			cancel()
+			err := <-done
+			e.release()
+			return microerror.Mask(err)
+		case <-ticker.C:
+			// The renewal must not outlive RenewDeadline, e.g. when a
+			// request hangs, so fn is stopped before another replica can
+			// take over the Lease.
+			now := time.Now()
+			renewCtx, renewCancel := context.WithTimeout(ctx, options.RenewDeadline-now.Sub(renewed))
+			acquired, err := e.tryAcquireOrRenew(renewCtx, now)
+			renewCancel()
+			if ctx.Err() != nil {
+				continue
+			} else if err == nil && acquired {
+				renewed = now
+			} else if err == nil || time.Since(renewed) >= options.RenewDeadline {
+				lost = true
+			}
+		}
+	}
+
+	cancel()
+	<-done
+
+	return microerror.Maskf(leaderElectionLostError, "Lease %#q", options.LeaseName)
+}
+
+type elector struct {
+	leases  ResourceInterface
+	options LeaderElectionOptions
+}
+
+// tryAcquireOrRenew creates or updates the Lease to be held by this replica.
+// It returns false when the Lease is held by another replica.
+func (e elector) tryAcquireOrRenew(ctx context.Context, now time.Time) (bool, error) {
+	lease, err := e.leases.Get(ctx, e.options.LeaseName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		lease = &unstructured.Unstructured{}
+		lease.SetAPIVersion(LeaseResource.GroupVersion().String())
+		lease.SetKind("Lease")
+		lease.SetName(e.options.LeaseName)
+		e.setHolder(lease, now, 0)
+
+		_, err = e.leases.Create(ctx, lease, metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			return false, nil
+		} else if err != nil {
+			return false, microerror.Mask(err)
+		}
+
+		return true, nil
+	} else if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	holder, _, _ := unstructured.NestedString(lease.Object, "spec", "holderIdentity")
+	transitions, _, _ := unstructured.NestedInt64(lease.Object, "spec", "leaseTransitions")
+	if holder != e.options.Identity {
+		if holder != "" && !leaseExpired(lease, now) {
+			return false, nil
+		}
+		transitions++
+	}
+
+	e.setHolder(lease, now, transitions)
+	_, err = e.leases.Update(ctx, lease, metav1.UpdateOptions{})
+	if apierrors.IsConflict(err) {
+		return false, nil
+	} else if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return true, nil
+}
+
+// isPermanentLeaseError returns true for the errors of tryAcquireOrRenew
+// which retrying can not fix.
+func isPermanentLeaseError(err error) bool {
+	err = microerror.Cause(err)
+
+	return apierrors.IsForbidden(err) ||
+		apierrors.IsUnauthorized(err) ||
+		apierrors.IsNotFound(err) ||
+		apierrors.IsInvalid(err) ||
+		apierrors.IsBadRequest(err) ||
+		apierrors.IsMethodNotSupported(err)
+}
+
+// release gives up the Lease so another replica can take over without
+// waiting for it to expire.
+func (e elector) release() {
+	ctx, cancel := context.WithTimeout(context.Background(), e.options.RetryPeriod)
+	defer cancel()
+
+	lease, err := e.leases.Get(ctx, e.options.LeaseName, metav1.GetOptions{})
+	if err != nil {
+		return
+	}
+	holder, _, _ := unstructured.NestedString(lease.Object, "spec", "holderIdentity")
+	if holder != e.options.Identity {
+		return
+	}
+
+	_ = unstructured.SetNestedField(lease.Object, "", "spec", "holderIdentity")
+	_ = unstructured.SetNestedField(lease.Object, int64(1), "spec", "leaseDurationSeconds")
+	_, _ = e.leases.Update(ctx, lease, metav1.UpdateOptions{})
+}
+
+func (e elector) setHolder(lease *unstructured.Unstructured, now time.Time, transitions int64) {
+	holder, _, _ := unstructured.NestedString(lease.Object, "spec", "holderIdentity")
+	renewTime := now.UTC().Format(metav1.RFC3339Micro)
+	if holder != e.options.Identity {
+		_ = unstructured.SetNestedField(lease.Object, renewTime, "spec", "acquireTime")
+	}
+	_ = unstructured.SetNestedField(lease.Object, e.options.Identity, "spec", "holderIdentity")
+	_ = unstructured.SetNestedField(lease.Object, int64(e.options.LeaseDuration/time.Second), "spec", "leaseDurationSeconds")
+	_ = unstructured.SetNestedField(lease.Object, renewTime, "spec", "renewTime")
+	_ = unstructured.SetNestedField(lease.Object, transitions, "spec", "leaseTransitions")
+}
+
+func leaseExpired(lease *unstructured.Unstructured, now time.Time) bool {
+	renewTime, _, _ := unstructured.NestedString(lease.Object, "spec", "renewTime")
+	seconds, _, _ := unstructured.NestedInt64(lease.Object, "spec", "leaseDurationSeconds")
+
+	renewed, err := time.Parse(metav1.RFC3339Micro, renewTime)
+	if err != nil {
+		return true
+	}
+
+	return now.After(renewed.Add(time.Duration(seconds) * time.Second))
+}
@@ -0,0 +1,112 @@
+package argoappclient
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_RunWithLeaderElection(t *testing.T) {
+	testCases := []struct {
+		name string
+		// update is the Update of the leases client, nil stores the Lease.
+		update func(ctx context.Context, obj *unstructured.Unstructured) (*unstructured.Unstructured, error)
+		// wait makes fn run until its context is cancelled.
+		wait           bool
+		expectedLost   bool
+		expectedHolder string
+	}{
+		{
+			name:           "case 0: Lease is acquired and released when fn returns",
+			update:         nil,
+			wait:           false,
+			expectedLost:   false,
+			expectedHolder: "",
+		},
+		{
+			name: "case 1: Lease is lost when renewing fails",
+			update: func(ctx context.Context, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+				return nil, apierrors.NewServiceUnavailable("unavailable")
+			},
+			wait:           true,
+			expectedLost:   true,
+			expectedHolder: "replica-0",
+		},
+		{
+			name: "case 2: Lease is lost when renewing hangs",
+			update: func(ctx context.Context, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+			wait:           true,
+			expectedLost:   true,
+			expectedHolder: "replica-0",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			leases := newFakeResource()
+			leases.update = tc.update
+
+			options := LeaderElectionOptions{
+				LeaseName:     "argoapp",
+				Identity:      "replica-0",
+				LeaseDuration: time.Second,
+				RenewDeadline: 300 * time.Millisecond,
+				RetryPeriod:   50 * time.Millisecond,
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			var started time.Time
+			fn := func(ctx context.Context) error {
+				started = time.Now()
+
+				lease, err := leases.Get(ctx, options.LeaseName, metav1.GetOptions{})
+				if err != nil {
+					return err
+				}
+				holder, _, _ := unstructured.NestedString(lease.Object, "spec", "holderIdentity")
+				if holder != options.Identity {
+					t.Errorf("expected Lease holder %#q while fn runs, got %#q", options.Identity, holder)
+				}
+
+				if tc.wait {
+					<-ctx.Done()
+				}
+				return nil
+			}
+
+			err := RunWithLeaderElection(ctx, leases, options, fn)
+			if tc.expectedLost && !IsLeaderElectionLost(err) {
+				t.Fatalf("expected leader election lost error, got %v", err)
+			} else if !tc.expectedLost && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			// fn must be stopped before another replica can take over the
+			// Lease.
+			if elapsed := time.Since(started); elapsed >= options.LeaseDuration {
+				t.Fatalf("expected fn to be stopped within %s, took %s", options.LeaseDuration, elapsed)
+			}
+
+			lease, err := leases.Get(context.Background(), options.LeaseName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			holder, _, _ := unstructured.NestedString(lease.Object, "spec", "holderIdentity")
+			if holder != tc.expectedHolder {
+				t.Fatalf("expected Lease holder %#q, got %#q", tc.expectedHolder, holder)
+			}
+		})
+	}
+}
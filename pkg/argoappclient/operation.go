@@ -0,0 +1,97 @@
+package argoappclient
+
+import (
+	"context"
+
+	"github.com/giantswarm/microerror"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// OperationStrategy is how the update and delete helpers handle an
+// Application with a running operation, e.g. a sync. Changing an Application
+// mid-sync can leave its resources partially synced.
+type OperationStrategy string
+
+const (
+	// OperationStrategyIgnore changes the Application regardless of the
+	// running operation. It is the default.
+	OperationStrategyIgnore OperationStrategy = ""
+	// OperationStrategyFail returns an error matched by
+	// IsOperationRunning.
+	OperationStrategyFail OperationStrategy = "fail"
+	// OperationStrategyWait waits for the operation to finish.
+	OperationStrategyWait OperationStrategy = "wait"
+	// OperationStrategyTerminate terminates the operation and waits for it
+	// to stop.
+	OperationStrategyTerminate OperationStrategy = "terminate"
+	// OperationStrategyQueue records the changes in the
+	// PendingChangesAnnotation and returns an error matched by
+	// IsChangesQueued. The changes are applied with ApplyPendingChanges once
+	// the operation finishes. It is supported only by the apply helpers.
+	OperationStrategyQueue OperationStrategy = "queue"
+)
+
+// IsOperationInProgress returns true when the Application has a running or
+// terminating operation.
+func IsOperationInProgress(app *unstructured.Unstructured) bool {
+	phase, _, _ := unstructured.NestedString(app.Object, "status", "operationState", "phase")
+	return phase == "Running" || phase == "Terminating"
+}
+
+// TerminateOperation requests termination of the running operation of the
+// Application with the given name the same way as `argocd app terminate-op`.
+// It does not wait for the operation to stop.
+func TerminateOperation(ctx context.Context, applications ResourceInterface, name string) error {
+	patch := []byte(`{"status":{"operationState":{"phase":"Terminating"}}}`)
+	_, err := applications.Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// handleRunningOperation applies the strategy to the Application with the
+// given name. It returns when the Application can be changed.
+func handleRunningOperation(ctx context.Context, applications ResourceInterface, name string, strategy OperationStrategy, wait WaitOptions) error {
+	switch strategy {
+	case OperationStrategyIgnore:
+		return nil
+	case OperationStrategyFail, OperationStrategyWait, OperationStrategyTerminate:
+	default:
+		return microerror.Maskf(invalidConfigError, "operation strategy %#q is not supported", strategy)
+	}
+
+	app, err := applications.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if !IsOperationInProgress(app) {
+		return nil
+	}
+
+	switch strategy {
+	case OperationStrategyFail:
+		return microerror.Maskf(operationRunningError, "Application %#q", name)
+	case OperationStrategyTerminate:
+		err = TerminateOperation(ctx, applications, name)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	_, err = waitFor(ctx, applications, name, wait, func(app *unstructured.Unstructured) bool {
+		return !IsOperationInProgress(app)
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
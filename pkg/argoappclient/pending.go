@@ -0,0 +1,151 @@
+package argoappclient
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/giantswarm/microerror"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// PendingChangesAnnotation holds the JSON encoded Application queued with
+// OperationStrategyQueue while the live Application had a running operation.
+// It is applied by ApplyPendingChanges once the operation finishes. Only the
+// latest queued Application is kept.
+const PendingChangesAnnotation = "argoapp.giantswarm.io/pending-changes"
+
+// queueIfOperationInProgress records obj as the pending changes when the live
+// Application has a running operation. It returns true when the changes were
+// queued.
+func queueIfOperationInProgress(ctx context.Context, applications ResourceInterface, obj *unstructured.Unstructured) (bool, error) {
+	app, err := applications.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	if !IsOperationInProgress(app) {
+		return false, nil
+	}
+
+	err = queuePendingChanges(ctx, applications, obj)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return true, nil
+}
+
+// queuePendingChanges records obj as the pending changes of the live
+// Application.
+func queuePendingChanges(ctx context.Context, applications ResourceInterface, obj *unstructured.Unstructured) error {
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				PendingChangesAnnotation: string(data),
+			},
+		},
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	_, err = applications.Patch(ctx, obj.GetName(), types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// ApplyPendingChanges applies the changes queued for the Application with
+// the given name unless it still has a running operation. It returns true
+// when the changes were applied. options.OperationStrategy is ignored.
+func ApplyPendingChanges(ctx context.Context, applications ResourceInterface, name string, options ApplyOptions) (bool, error) {
+	app, err := applications.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	applied, err := applyPendingChanges(ctx, applications, app, options)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return applied, nil
+}
+
+// ApplyAllPendingChanges calls ApplyPendingChanges for all the Applications
+// with pending changes and returns the names of the ones applied.
+func ApplyAllPendingChanges(ctx context.Context, applications ResourceInterface, options ApplyOptions) ([]string, error) {
+	list, err := applications.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var names []string
+	for i := range list.Items {
+		applied, err := applyPendingChanges(ctx, applications, &list.Items[i], options)
+		if err != nil {
+			return names, microerror.Mask(err)
+		}
+		if applied {
+			names = append(names, list.Items[i].GetName())
+		}
+	}
+
+	return names, nil
+}
+
+func applyPendingChanges(ctx context.Context, applications ResourceInterface, app *unstructured.Unstructured, options ApplyOptions) (bool, error) {
+	data, ok := app.GetAnnotations()[PendingChangesAnnotation]
+	if !ok || IsOperationInProgress(app) {
+		return false, nil
+	}
+
+	obj := &unstructured.Unstructured{}
+	err := json.Unmarshal([]byte(data), &obj.Object)
+	if err != nil {
+		return false, microerror.Maskf(invalidConfigError, "annotation %#q of Application %#q is invalid: %s", PendingChangesAnnotation, app.GetName(), err)
+	}
+
+	options.OperationStrategy = OperationStrategyIgnore
+	applied, err := ApplyApplicationWithOptions(ctx, applications, obj, options)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	// The resourceVersion precondition makes the removal fail when other
+	// changes were queued in the meantime, so they are not lost.
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"resourceVersion": applied.GetResourceVersion(),
+			"annotations": map[string]interface{}{
+				PendingChangesAnnotation: nil,
+			},
+		},
+	})
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	_, err = applications.Patch(ctx, app.GetName(), types.MergePatchType, patch, metav1.PatchOptions{})
+	if apierrors.IsConflict(err) {
+		return true, nil
+	} else if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,126 @@
+package argoappclient
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/giantswarm/argoapp/pkg/argoapp"
+)
+
+// UnpinExpired unpins the Applications generated by package argoapp which
+// were pinned with argoapp.PinConfigRef until a time before now (see
+// argoapp.PinOptions.Duration). It returns the names of the unpinned
+// Applications.
+func UnpinExpired(ctx context.Context, applications ResourceInterface, now time.Time) ([]string, error) {
+	selector := labels.SelectorFromSet(labels.Set{
+		argoapp.ManagedByLabel: argoapp.ManagedByLabelValue,
+	})
+
+	list, err := applications.List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var unpinned []string
+	for _, app := range list.Items {
+		expired, err := argoapp.IsPinExpired(&app, now)
+		if err != nil {
+			return unpinned, microerror.Mask(err)
+		}
+		if !expired {
+			continue
+		}
+
+		obj, err := argoapp.UnpinConfigRef(&app)
+		if err != nil {
+			return unpinned, microerror.Mask(err)
+		}
+
+		// The resourceVersion of the listed Application makes the update
+		// fail with a conflict instead of overwriting concurrent changes.
+		_, err = applications.Update(ctx, obj, metav1.UpdateOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return unpinned, microerror.Mask(err)
+		}
+
+		unpinned = append(unpinned, app.GetName())
+	}
+
+	return unpinned, nil
+}
+
+// keepPin sets the targetRevision of obj to the one of the existing
+// Application when it is pinned with argoapp.PinConfigRef and obj is not, so
+// applying obj does not conflict with or revert the pin. It returns the
+// targetRevision of obj to be recorded in the argoapp.PinnedFromAnnotation
+// instead, like argoapp.UpdateApplicationConfig does.
+func keepPin(ctx context.Context, applications ResourceInterface, obj *unstructured.Unstructured) (string, bool, error) {
+	if argoapp.IsPinned(obj) {
+		return "", false, nil
+	}
+
+	existing, err := applications.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, microerror.Mask(err)
+	}
+	if !argoapp.IsPinned(existing) {
+		return "", false, nil
+	}
+
+	pinned, _, err := unstructured.NestedString(existing.Object, "spec", "source", "targetRevision")
+	if err != nil {
+		return "", false, microerror.Mask(err)
+	}
+	configRef, _, err := unstructured.NestedString(obj.Object, "spec", "source", "targetRevision")
+	if err != nil {
+		return "", false, microerror.Mask(err)
+	}
+
+	err = unstructured.SetNestedField(obj.Object, pinned, "spec", "source", "targetRevision")
+	if err != nil {
+		return "", false, microerror.Mask(err)
+	}
+
+	return configRef, true, nil
+}
+
+// recordPinnedFrom sets the argoapp.PinnedFromAnnotation of the Application
+// with a merge patch. The annotation is not part of the applied object so the
+// field manager of the pin keeps owning it and the integrity record of the
+// Application does not depend on the pin.
+func recordPinnedFrom(ctx context.Context, applications ResourceInterface, applied *unstructured.Unstructured, configRef string, fieldManager string) (*unstructured.Unstructured, error) {
+	if applied.GetAnnotations()[argoapp.PinnedFromAnnotation] == configRef {
+		return applied, nil
+	}
+
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				argoapp.PinnedFromAnnotation: configRef,
+			},
+		},
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	patched, err := applications.Patch(ctx, applied.GetName(), types.MergePatchType, data, metav1.PatchOptions{FieldManager: fieldManager})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return patched, nil
+}
@@ -0,0 +1,26 @@
+package argoappclient
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/argoapp/pkg/argoapp"
+)
+
+// newProtectedError returns the error reporting the objects with the
+// argoapp.ProtectedAnnotation a helper skipped. It returns nil when no
+// objects were skipped.
+func newProtectedError(kind string, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	var quoted []string
+	for _, n := range names {
+		quoted = append(quoted, fmt.Sprintf("%#q", n))
+	}
+
+	return microerror.Maskf(protectedError, "skipped deleting %s %s protected with the %#q annotation", kind, strings.Join(quoted, ", "), argoapp.ProtectedAnnotation)
+}
@@ -0,0 +1,112 @@
+package argoappclient
+
+import (
+	"context"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/giantswarm/argoapp/pkg/argoapp"
+)
+
+type ReapOptions struct {
+	// Force deletes the expired Applications even when they are protected
+	// with the argoapp.ProtectedAnnotation.
+	Force bool
+}
+
+// Reap deletes the Applications generated by package argoapp which expired
+// before now (see argoapp.ApplicationConfig.TTL). It returns the names of the
+// deleted Applications. Applications protected with the
+// argoapp.ProtectedAnnotation are skipped and reported with an error matched
+// by IsProtected after the others are deleted.
+func Reap(ctx context.Context, applications ResourceInterface, now time.Time) ([]string, error) {
+	deleted, err := ReapWithOptions(ctx, applications, now, ReapOptions{})
+	if err != nil {
+		return deleted, microerror.Mask(err)
+	}
+
+	return deleted, nil
+}
+
+// ReapWithOptions is like Reap but configured with options.
+func ReapWithOptions(ctx context.Context, applications ResourceInterface, now time.Time, options ReapOptions) ([]string, error) {
+	selector := labels.SelectorFromSet(labels.Set{
+		argoapp.ManagedByLabel: argoapp.ManagedByLabelValue,
+	})
+
+	deleted, err := reap(ctx, applications, selector, now, options)
+	if err != nil {
+		return deleted, microerror.Mask(err)
+	}
+
+	return deleted, nil
+}
+
+// ReapPreviews deletes the preview Applications (see
+// argoapp.NewPreviewApplication) which expired before now. It returns the
+// names of the deleted Applications. Protected Applications are skipped like
+// with Reap.
+func ReapPreviews(ctx context.Context, applications ResourceInterface, now time.Time) ([]string, error) {
+	deleted, err := ReapPreviewsWithOptions(ctx, applications, now, ReapOptions{})
+	if err != nil {
+		return deleted, microerror.Mask(err)
+	}
+
+	return deleted, nil
+}
+
+// ReapPreviewsWithOptions is like ReapPreviews but configured with options.
+func ReapPreviewsWithOptions(ctx context.Context, applications ResourceInterface, now time.Time, options ReapOptions) ([]string, error) {
+	selector := labels.SelectorFromSet(labels.Set{
+		argoapp.PreviewLabel: "true",
+	})
+
+	deleted, err := reap(ctx, applications, selector, now, options)
+	if err != nil {
+		return deleted, microerror.Mask(err)
+	}
+
+	return deleted, nil
+}
+
+func reap(ctx context.Context, applications ResourceInterface, selector labels.Selector, now time.Time, options ReapOptions) ([]string, error) {
+	list, err := applications.List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var deleted, protected []string
+	for _, app := range list.Items {
+		expired, err := argoapp.IsExpired(&app, now)
+		if err != nil {
+			return deleted, microerror.Mask(err)
+		}
+		if !expired {
+			continue
+		}
+		if argoapp.IsProtected(&app) && !options.Force {
+			protected = append(protected, app.GetName())
+			continue
+		}
+
+		err = applications.Delete(ctx, app.GetName(), metav1.DeleteOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return deleted, microerror.Mask(err)
+		}
+
+		deleted = append(deleted, app.GetName())
+	}
+
+	err = newProtectedError("Applications", protected)
+	if err != nil {
+		return deleted, microerror.Mask(err)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,93 @@
+package argoappclient
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/giantswarm/microerror"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// RefreshAnnotation requests Argo CD to refresh the Application. Argo CD
+// removes it once the refresh is done.
+const RefreshAnnotation = "argocd.argoproj.io/refresh"
+
+// RefreshType is the value of the RefreshAnnotation.
+type RefreshType string
+
+const (
+	// RefreshTypeNormal compares the Application with the cached manifests
+	// of its source.
+	RefreshTypeNormal RefreshType = "normal"
+	// RefreshTypeHard regenerates the manifests of the Application source,
+	// e.g. to pick up changes of the config repository plugin output.
+	RefreshTypeHard RefreshType = "hard"
+)
+
+// IsRefreshRequested returns true when the Application has a refresh
+// requested with the RefreshAnnotation which Argo CD did not do yet.
+func IsRefreshRequested(app *unstructured.Unstructured) bool {
+	_, ok := app.GetAnnotations()[RefreshAnnotation]
+	return ok
+}
+
+// RequestRefresh requests a refresh of the Application with the given name
+// the same way as `argocd app get --refresh` and `--hard-refresh`. It does
+// not wait for the refresh. See RequestRefreshAndWait.
+func RequestRefresh(ctx context.Context, applications ResourceInterface, name string, refreshType RefreshType) error {
+	if refreshType != RefreshTypeNormal && refreshType != RefreshTypeHard {
+		return microerror.Maskf(invalidConfigError, "refresh type %#q is not supported", refreshType)
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				RefreshAnnotation: string(refreshType),
+			},
+		},
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	_, err = applications.Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// RequestRefreshAndWait is like RequestRefresh but also polls the
+// Application until Argo CD removed the RefreshAnnotation and
+// status.reconciledAt advanced, and returns the refreshed Application. It
+// returns a *WaitTimeoutError when the timeout expires.
+func RequestRefreshAndWait(ctx context.Context, applications ResourceInterface, name string, refreshType RefreshType, opts WaitOptions) (*unstructured.Unstructured, error) {
+	app, err := applications.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	reconciledAt := getReconciledAt(app)
+
+	err = RequestRefresh(ctx, applications, name, refreshType)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	refreshed := func(app *unstructured.Unstructured) bool {
+		return !IsRefreshRequested(app) && getReconciledAt(app) != reconciledAt
+	}
+	app, err = waitFor(ctx, applications, name, opts, refreshed)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return app, nil
+}
+
+func getReconciledAt(app *unstructured.Unstructured) string {
+	reconciledAt, _, _ := unstructured.NestedString(app.Object, "status", "reconciledAt")
+	return reconciledAt
+}
@@ -0,0 +1,147 @@
+package argoappclient
+
+import (
+	"context"
+
+	"github.com/giantswarm/microerror"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Notifier is notified when an Application is rolled back, e.g. to emit a
+// Kubernetes Event or send a chat message.
+type Notifier interface {
+	Notify(ctx context.Context, app *unstructured.Unstructured, reason string)
+}
+
+type VerifyOrRollbackConfig struct {
+	Applications ResourceInterface
+	Checks       *PostSyncChecks
+
+	// Previous is the Application as it was before the update being
+	// verified. When verification fails its spec is restored. Rollback is
+	// disabled when Previous is nil.
+	Previous *unstructured.Unstructured
+	// Notifier is optional.
+	Notifier Notifier
+}
+
+// VerifyOrRollback runs the post-sync checks for the Application with the
+// given name. When verification fails and config.Previous is set, the
+// Application spec is rolled back to the config.Previous spec and the Notifier
+// is called. The verification error is returned in both cases so the caller
+// knows the update did not succeed.
+func VerifyOrRollback(ctx context.Context, name string, config VerifyOrRollbackConfig) error {
+	if config.Applications == nil {
+		return microerror.Maskf(invalidConfigError, "%T.Applications must not be empty", config)
+	}
+	if config.Checks == nil {
+		return microerror.Maskf(invalidConfigError, "%T.Checks must not be empty", config)
+	}
+
+	app, err := config.Applications.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	verificationErr := config.Checks.Run(ctx, app)
+	if verificationErr == nil || config.Previous == nil {
+		return microerror.Mask(verificationErr)
+	}
+
+	spec, ok, err := unstructured.NestedFieldCopy(config.Previous.Object, "spec")
+	if err != nil {
+		return microerror.Mask(err)
+	} else if !ok {
+		return microerror.Maskf(invalidConfigError, "%T.Previous has no spec", config)
+	}
+	app.Object["spec"] = spec
+
+	_, err = config.Applications.Update(ctx, app, metav1.UpdateOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if config.Notifier != nil {
+		config.Notifier.Notify(ctx, app, verificationErr.Error())
+	}
+
+	return microerror.Mask(verificationErr)
+}
+
+// Rollback rolls the Application with the given name back to the revision
+// deployed by the status.history entry with the given ID, the same way as
+// `argocd app rollback`. It submits the sync operation and does not wait for
+// it to finish, see WaitForSynced.
+//
+// Like Argo CD it returns an error matched by IsAutoSyncEnabled when
+// automated syncing is enabled, as it would immediately sync the Application
+// forward again, and an error matched by IsOperationRunning when the
+// Application has an operation in progress.
+func Rollback(ctx context.Context, applications ResourceInterface, name string, historyID int64) error {
+	app, err := applications.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if _, ok, _ := unstructured.NestedFieldNoCopy(app.Object, "spec", "syncPolicy", "automated"); ok {
+		return microerror.Maskf(autoSyncEnabledError, "Application %#q can not be rolled back with automated syncing enabled", name)
+	}
+	if _, ok := app.Object["operation"]; ok || IsOperationInProgress(app) {
+		return microerror.Maskf(operationRunningError, "Application %#q", name)
+	}
+
+	entry, err := findHistoryEntry(app, historyID)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	sync := map[string]interface{}{
+		"syncStrategy": map[string]interface{}{
+			"apply": map[string]interface{}{},
+		},
+	}
+	// Multi-source Applications record revisions and sources instead.
+	for _, field := range []string{"revision", "source", "revisions", "sources"} {
+		if v, ok := entry[field]; ok {
+			sync[field] = v
+		}
+	}
+	app.Object["operation"] = map[string]interface{}{
+		"initiatedBy": map[string]interface{}{
+			"username": "argoapp",
+		},
+		"sync": sync,
+	}
+
+	// Update fails on conflict, e.g. when an operation was started in the
+	// meantime.
+	_, err = applications.Update(ctx, app, metav1.UpdateOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// findHistoryEntry returns a copy of the status.history entry of the
+// Application with the given ID.
+func findHistoryEntry(app *unstructured.Unstructured, id int64) (map[string]interface{}, error) {
+	history, _, err := unstructured.NestedSlice(app.Object, "status", "history")
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	for _, item := range history {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		entryID, _, _ := unstructured.NestedInt64(entry, "id")
+		if entryID == id {
+			return entry, nil
+		}
+	}
+
+	return nil, microerror.Maskf(invalidConfigError, "Application %#q has no history entry with ID %d", app.GetName(), id)
+}
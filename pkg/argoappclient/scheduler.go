@@ -0,0 +1,307 @@
+package argoappclient
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/giantswarm/argoapp/pkg/argoapp"
+)
+
+const (
+	defaultSchedulerMaxConcurrent           = 10
+	defaultSchedulerMaxConcurrentPerCluster = 2
+)
+
+type SyncSchedulerConfig struct {
+	Applications ResourceInterface
+
+	// MaxConcurrent is the total weight of the syncs running at the same
+	// time. Defaults to 10.
+	MaxConcurrent int
+	// MaxConcurrentPerCluster is the number of syncs running at the same
+	// time per destination cluster. Defaults to 2.
+	MaxConcurrentPerCluster int
+	// Weight is optional. It returns the share of MaxConcurrent taken by the
+	// sync of the Application, e.g. more for Applications rendering many
+	// manifests on the repo-server. Defaults to 1. Weights above
+	// MaxConcurrent are capped to it.
+	Weight func(app *unstructured.Unstructured) int
+	// Wait configures waiting for each sync to finish. A sync holds its
+	// share of the limits until it finishes.
+	Wait WaitOptions
+}
+
+// SyncScheduler syncs many Applications, e.g. after a ConfigRef rollout,
+// without overloading the repo-server and the workload clusters.
+type SyncScheduler struct {
+	applications            ResourceInterface
+	maxConcurrent           int
+	maxConcurrentPerCluster int
+	weight                  func(app *unstructured.Unstructured) int
+	wait                    WaitOptions
+}
+
+func NewSyncScheduler(config SyncSchedulerConfig) (*SyncScheduler, error) {
+	if config.Applications == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Applications must not be empty", config)
+	}
+	if config.MaxConcurrent < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.MaxConcurrent must not be negative", config)
+	}
+	if config.MaxConcurrent == 0 {
+		config.MaxConcurrent = defaultSchedulerMaxConcurrent
+	}
+	if config.MaxConcurrentPerCluster < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.MaxConcurrentPerCluster must not be negative", config)
+	}
+	if config.MaxConcurrentPerCluster == 0 {
+		config.MaxConcurrentPerCluster = defaultSchedulerMaxConcurrentPerCluster
+	}
+
+	s := &SyncScheduler{
+		applications:            config.Applications,
+		maxConcurrent:           config.MaxConcurrent,
+		maxConcurrentPerCluster: config.MaxConcurrentPerCluster,
+		weight:                  config.Weight,
+		wait:                    config.Wait,
+	}
+
+	return s, nil
+}
+
+// syncTask is a scheduled sync of an Application.
+type syncTask struct {
+	name    string
+	cluster string
+	wave    int
+	weight  int
+}
+
+type syncResult struct {
+	task syncTask
+	err  error
+}
+
+// Sync triggers a sync of the Applications with the given names and waits for
+// the syncs to finish. The Applications are synced in the ascending order of
+// their argoapp.SyncWaveAnnotation and a wave starts once all the syncs of the
+// previous wave finished, so dependencies are synced first. Within a wave the
+// Applications with the highest weight are started first.
+//
+// Failed syncs do not stop their wave but the later waves are not started.
+// The failures are returned in an error matched by IsExecutionFailed.
+// Applications frozen with the argoapp.FrozenAnnotation are not synced, they
+// are returned in an error matched by IsFrozen when nothing failed.
+func (s *SyncScheduler) Sync(ctx context.Context, names []string) error {
+	var tasks []syncTask
+	var frozen []string
+	for _, name := range names {
+		app, err := s.applications.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		if argoapp.IsFrozen(app) {
+			frozen = append(frozen, name)
+			continue
+		}
+
+		t, err := s.newSyncTask(app)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		tasks = append(tasks, t)
+	}
+
+	sort.SliceStable(tasks, func(i, j int) bool {
+		if tasks[i].wave != tasks[j].wave {
+			return tasks[i].wave < tasks[j].wave
+		}
+		if tasks[i].weight != tasks[j].weight {
+			return tasks[i].weight > tasks[j].weight
+		}
+		return tasks[i].name < tasks[j].name
+	})
+
+	for len(tasks) > 0 {
+		n := 1
+		for n < len(tasks) && tasks[n].wave == tasks[0].wave {
+			n++
+		}
+
+		failures := s.runWave(ctx, tasks[:n])
+		if ctx.Err() != nil {
+			return microerror.Mask(ctx.Err())
+		}
+		tasks = tasks[n:]
+
+		if len(failures) > 0 {
+			msg := strings.Join(failures, "; ")
+			if len(tasks) > 0 {
+				msg += fmt.Sprintf(", %d Applications of later waves not synced", len(tasks))
+			}
+			return microerror.Maskf(executionFailedError, "%d Applications failed to sync: %s", len(failures), msg)
+		}
+	}
+
+	if len(frozen) > 0 {
+		return microerror.Maskf(frozenError, "skipped Applications %s frozen with the %#q annotation", strings.Join(frozen, ", "), argoapp.FrozenAnnotation)
+	}
+
+	return nil
+}
+
+func (s *SyncScheduler) newSyncTask(app *unstructured.Unstructured) (syncTask, error) {
+	t := syncTask{
+		name:    app.GetName(),
+		cluster: destinationCluster(app),
+		weight:  1,
+	}
+
+	if v, ok := app.GetAnnotations()[argoapp.SyncWaveAnnotation]; ok {
+		wave, err := strconv.Atoi(v)
+		if err != nil {
+			return syncTask{}, microerror.Maskf(invalidConfigError, "Application %#q annotation %#q value %#q is invalid: %s", t.name, argoapp.SyncWaveAnnotation, v, err)
+		}
+		t.wave = wave
+	}
+
+	if s.weight != nil {
+		t.weight = s.weight(app)
+	}
+	if t.weight < 1 {
+		t.weight = 1
+	}
+	if t.weight > s.maxConcurrent {
+		t.weight = s.maxConcurrent
+	}
+
+	return t, nil
+}
+
+// runWave syncs the tasks within the limits and returns the failures. A task
+// blocked by the limit of its cluster is passed over, a task blocked by
+// MaxConcurrent is not so heavier tasks are not starved by lighter ones. No
+// task is started once ctx is cancelled.
+func (s *SyncScheduler) runWave(ctx context.Context, pending []syncTask) []string {
+	results := make(chan syncResult)
+
+	var failures []string
+	var running, used int
+	perCluster := map[string]int{}
+	for {
+		for ctx.Err() == nil {
+			i, ok := s.next(pending, used, perCluster)
+			if !ok {
+				break
+			}
+
+			t := pending[i]
+			pending = append(pending[:i:i], pending[i+1:]...)
+			running++
+			used += t.weight
+			perCluster[t.cluster]++
+
+			go func() {
+				results <- syncResult{task: t, err: s.syncApplication(ctx, t.name)}
+			}()
+		}
+
+		if running == 0 {
+			return failures
+		}
+
+		r := <-results
+		running--
+		used -= r.task.weight
+		perCluster[r.task.cluster]--
+		if r.err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", r.task.name, r.err))
+		}
+	}
+}
+
+// next returns the index of the pending task to start next.
+func (s *SyncScheduler) next(pending []syncTask, used int, perCluster map[string]int) (int, bool) {
+	for i, t := range pending {
+		if perCluster[t.cluster] >= s.maxConcurrentPerCluster {
+			continue
+		}
+		if used+t.weight > s.maxConcurrent {
+			return 0, false
+		}
+		return i, true
+	}
+
+	return 0, false
+}
+
+// syncApplication submits a sync operation of the Application with the given
+// name and waits for it to finish.
+func (s *SyncScheduler) syncApplication(ctx context.Context, name string) error {
+	app, err := s.applications.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if _, ok := app.Object["operation"]; ok || IsOperationInProgress(app) {
+		return microerror.Maskf(operationRunningError, "Application %#q", name)
+	}
+
+	app.Object["operation"] = map[string]interface{}{
+		"initiatedBy": map[string]interface{}{
+			"username": "argoapp",
+		},
+		"sync": map[string]interface{}{},
+	}
+
+	// Update fails on conflict, e.g. when an operation was started in the
+	// meantime.
+	_, err = s.applications.Update(ctx, app, metav1.UpdateOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// The operation field is removed by Argo CD once the operation
+	// finished.
+	app, err = waitFor(ctx, s.applications, name, s.wait, func(app *unstructured.Unstructured) bool {
+		_, ok := app.Object["operation"]
+		return !ok && !IsOperationInProgress(app)
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	phase, _, _ := unstructured.NestedString(app.Object, "status", "operationState", "phase")
+	if phase != "Succeeded" {
+		msg := fmt.Sprintf("sync of Application %#q finished with phase %#q", name, phase)
+		if message, _, _ := unstructured.NestedString(app.Object, "status", "operationState", "message"); message != "" {
+			msg += ": " + message
+		}
+		return microerror.Maskf(executionFailedError, "%s", msg)
+	}
+
+	return nil
+}
+
+// destinationCluster returns the name identifying the destination cluster of
+// the Application.
+func destinationCluster(app *unstructured.Unstructured) string {
+	name, _, _ := unstructured.NestedString(app.Object, "spec", "destination", "name")
+	if name != "" {
+		return name
+	}
+
+	server, _, _ := unstructured.NestedString(app.Object, "spec", "destination", "server")
+	if server == inClusterServer {
+		return inClusterName
+	}
+
+	return server
+}
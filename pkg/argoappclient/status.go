@@ -0,0 +1,70 @@
+package argoappclient
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ResourceStatus is the status of a resource managed by an Application as
+// listed in its status.resources.
+type ResourceStatus struct {
+	Group     string
+	Version   string
+	Kind      string
+	Namespace string
+	Name      string
+	// Status is the sync status, e.g. "Synced" or "OutOfSync".
+	Status string
+	// Health is the health status, e.g. "Healthy" or "Degraded". It is
+	// empty for resources without health assessment.
+	Health        string
+	HealthMessage string
+}
+
+// IsSynced returns true when the Application sync status is Synced.
+func IsSynced(app *unstructured.Unstructured) bool {
+	status, _, _ := unstructured.NestedString(app.Object, "status", "sync", "status")
+	return status == "Synced"
+}
+
+// IsHealthy returns true when the Application health status is Healthy.
+func IsHealthy(app *unstructured.Unstructured) bool {
+	status, _, _ := unstructured.NestedString(app.Object, "status", "health", "status")
+	return status == "Healthy"
+}
+
+// LastSyncSucceeded returns true when the last finished sync operation of
+// the Application succeeded. It returns false when there was no sync yet.
+func LastSyncSucceeded(app *unstructured.Unstructured) bool {
+	phase, _, _ := unstructured.NestedString(app.Object, "status", "operationState", "phase")
+	return phase == "Succeeded"
+}
+
+// DegradedResources returns the resources of the Application with Degraded
+// or Missing health status.
+func DegradedResources(app *unstructured.Unstructured) []ResourceStatus {
+	resources, _, _ := unstructured.NestedSlice(app.Object, "status", "resources")
+
+	var degraded []ResourceStatus
+	for _, item := range resources {
+		r, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		s := ResourceStatus{}
+		s.Group, _, _ = unstructured.NestedString(r, "group")
+		s.Version, _, _ = unstructured.NestedString(r, "version")
+		s.Kind, _, _ = unstructured.NestedString(r, "kind")
+		s.Namespace, _, _ = unstructured.NestedString(r, "namespace")
+		s.Name, _, _ = unstructured.NestedString(r, "name")
+		s.Status, _, _ = unstructured.NestedString(r, "status")
+		s.Health, _, _ = unstructured.NestedString(r, "health", "status")
+		s.HealthMessage, _, _ = unstructured.NestedString(r, "health", "message")
+
+		if s.Health == "Degraded" || s.Health == "Missing" {
+			degraded = append(degraded, s)
+		}
+	}
+
+	return degraded
+}
@@ -0,0 +1,276 @@
+package argoappclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/giantswarm/argoapp/pkg/argoapp"
+)
+
+// SyncLoopLabel is set on the Applications applied by RunSyncLoop to the
+// SyncLoopOptions.Name. Only the Applications with the label are pruned.
+const SyncLoopLabel = "argoapp.giantswarm.io/sync-loop"
+
+const (
+	defaultSyncLoopResync = 5 * time.Minute
+	defaultSyncLoopJitter = 0.1
+	defaultSyncLoopDrain  = 30 * time.Second
+)
+
+// Source yields the desired ApplicationConfigs of RunSyncLoop.
+type Source interface {
+	Desired(ctx context.Context) ([]argoapp.ApplicationConfig, error)
+}
+
+// SourceFunc adapts a function to a Source, e.g. to read the configs from a
+// custom resource.
+type SourceFunc func(ctx context.Context) ([]argoapp.ApplicationConfig, error)
+
+func (f SourceFunc) Desired(ctx context.Context) ([]argoapp.ApplicationConfig, error) {
+	return f(ctx)
+}
+
+// FileSource reads the ApplicationConfigs from the YAML or JSON file at the
+// given path with argoapp.LoadApplicationConfigs.
+type FileSource string
+
+func (s FileSource) Desired(ctx context.Context) ([]argoapp.ApplicationConfig, error) {
+	configs, err := argoapp.LoadApplicationConfigs(string(s))
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return configs, nil
+}
+
+// HTTPSource fetches the ApplicationConfigs, in the FileSource format, from
+// the URL and decodes them with argoapp.DecodeApplicationConfigs.
+type HTTPSource struct {
+	URL string
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+func (s HTTPSource) Desired(ctx context.Context) ([]argoapp.ApplicationConfig, error) {
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, microerror.Maskf(executionFailedError, "GET %s returned %s", s.URL, res.Status)
+	}
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	configs, err := argoapp.DecodeApplicationConfigs(data)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return configs, nil
+}
+
+type SyncLoopOptions struct {
+	// Name identifies the loop. It is set as the SyncLoopLabel value so
+	// multiple loops can manage Applications in the same namespace.
+	Name string
+	// Resync is the interval between the iterations. Defaults to 5 minutes.
+	Resync time.Duration
+	// Jitter is the maximum factor of Resync added to each interval so
+	// multiple loops do not sync at the same time. Defaults to 0.1.
+	Jitter float64
+	// ApplyOptions configures applying the Applications.
+	ApplyOptions ApplyOptions
+	// DeleteOptions configures pruning the Applications.
+	DeleteOptions DeleteOptions
+	// DrainTimeout is how long the in-flight apply or delete is allowed to
+	// finish after ctx is cancelled. Defaults to 30 seconds.
+	DrainTimeout time.Duration
+	// OnError is optional. It is called with the error of each failed
+	// iteration and with the errors matched by IsFrozen of the Applications
+	// skipped because they are frozen. The loop continues with the next
+	// iteration.
+	OnError func(err error)
+}
+
+// RunSyncLoop applies the Applications of the ApplicationConfigs yielded by
+// the source and prunes the Applications it applied before which are not
+// desired anymore, every resync interval until ctx is cancelled. It allows
+// simple automation to run without an operator framework. An iteration in
+// which the source or any config fails prunes nothing. Applications protected
+// with the argoapp.ProtectedAnnotation are not pruned unless
+// DeleteOptions.Force is set, they are reported to OnError with an error
+// matched by IsProtected.
+//
+// When ctx is cancelled the in-flight apply or delete is given DrainTimeout
+// to finish and the rest of the iteration is skipped. Run it with
+// RunWithLeaderElection to run multiple replicas.
+func RunSyncLoop(ctx context.Context, source Source, applications ResourceInterface, options SyncLoopOptions) error {
+	if source == nil {
+		return microerror.Maskf(invalidConfigError, "source must not be empty")
+	}
+	if options.Name == "" {
+		return microerror.Maskf(invalidConfigError, "%T.Name must not be empty", options)
+	}
+	if options.Resync < 0 {
+		return microerror.Maskf(invalidConfigError, "%T.Resync must not be negative", options)
+	}
+	if options.Resync == 0 {
+		options.Resync = defaultSyncLoopResync
+	}
+	if options.Jitter < 0 {
+		return microerror.Maskf(invalidConfigError, "%T.Jitter must not be negative", options)
+	}
+	if options.Jitter == 0 {
+		options.Jitter = defaultSyncLoopJitter
+	}
+	if options.DrainTimeout < 0 {
+		return microerror.Maskf(invalidConfigError, "%T.DrainTimeout must not be negative", options)
+	}
+	if options.DrainTimeout == 0 {
+		options.DrainTimeout = defaultSyncLoopDrain
+	}
+
+	for {
+		err := drain(ctx, options.DrainTimeout, func(requestCtx context.Context) error {
+			return syncOnce(ctx, requestCtx, source, applications, options)
+		})
+		if err != nil && options.OnError != nil && ctx.Err() == nil {
+			options.OnError(err)
+		}
+
+		t := time.NewTimer(wait.Jitter(options.Resync, options.Jitter))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil
+		case <-t.C:
+		}
+	}
+}
+
+// drain runs fn with a request context which is cancelled drainTimeout after
+// ctx, so the requests in flight when ctx is cancelled can finish.
+func drain(ctx context.Context, drainTimeout time.Duration, fn func(requestCtx context.Context) error) error {
+	requestCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+		}
+
+		select {
+		case <-done:
+		case <-time.After(drainTimeout):
+			cancel()
+		}
+	}()
+
+	return fn(requestCtx)
+}
+
+// syncOnce runs a single iteration. It makes the requests with requestCtx and
+// stops before the next request when ctx is cancelled.
+func syncOnce(ctx, requestCtx context.Context, source Source, applications ResourceInterface, options SyncLoopOptions) error {
+	configs, err := source.Desired(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	apps, err := argoapp.NewApplicationCollection(configs)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	keep := map[string]bool{}
+	for _, app := range apps {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		l := app.GetLabels()
+		l[SyncLoopLabel] = options.Name
+		app.SetLabels(l)
+
+		err = argoapp.SealIntegrity(app)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		applied, err := ApplyApplicationWithOptions(requestCtx, applications, app, options.ApplyOptions)
+		if IsChangesQueued(err) {
+			keep[app.GetName()] = true
+			continue
+		} else if IsFrozen(err) {
+			// Frozen Applications are kept but do not fail the
+			// iteration.
+			keep[app.GetName()] = true
+			if options.OnError != nil {
+				options.OnError(err)
+			}
+			continue
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		keep[applied.GetName()] = true
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{
+		argoapp.ManagedByLabel: argoapp.ManagedByLabelValue,
+		SyncLoopLabel:          options.Name,
+	})
+	list, err := applications.List(requestCtx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	var protected []string
+	for _, app := range list.Items {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if keep[app.GetName()] {
+			continue
+		}
+
+		err = DeleteApplication(requestCtx, applications, app.GetName(), options.DeleteOptions)
+		if IsProtected(err) {
+			protected = append(protected, app.GetName())
+			continue
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	err = newProtectedError("Applications", protected)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
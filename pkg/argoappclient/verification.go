@@ -0,0 +1,182 @@
+package argoappclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/giantswarm/microerror"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Check verifies an Application after it reports Synced and Healthy, so
+// "deployed" can mean "verified".
+type Check interface {
+	// Name is used in error messages.
+	Name() string
+	// Check returns an error when the verification fails.
+	Check(ctx context.Context, app *unstructured.Unstructured) error
+}
+
+// PostSyncChecks holds Checks registered per Application name.
+//
+// PostSyncChecks is safe for concurrent use.
+type PostSyncChecks struct {
+	mutex  sync.RWMutex
+	checks map[string][]Check
+}
+
+func NewPostSyncChecks() *PostSyncChecks {
+	c := &PostSyncChecks{
+		checks: map[string][]Check{},
+	}
+
+	return c
+}
+
+// Register registers checks for the Application with the given name. Checks
+// registered for an empty name are run for all Applications.
+func (c *PostSyncChecks) Register(appName string, checks ...Check) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.checks[appName] = append(c.checks[appName], checks...)
+}
+
+// Run runs all the checks registered for the Application and returns an
+// error matched by IsVerificationFailed listing all failed checks.
+func (c *PostSyncChecks) Run(ctx context.Context, app *unstructured.Unstructured) error {
+	c.mutex.RLock()
+	var checks []Check
+	checks = append(checks, c.checks[""]...)
+	if app.GetName() != "" {
+		checks = append(checks, c.checks[app.GetName()]...)
+	}
+	c.mutex.RUnlock()
+
+	var failures []string
+	for _, check := range checks {
+		err := check.Check(ctx, app)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", check.Name(), err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return microerror.Maskf(verificationFailedError, "Application %#q: %s", app.GetName(), strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+// HTTPCheck passes when a GET request to URL returns ExpectedStatus.
+type HTTPCheck struct {
+	URL string
+	// ExpectedStatus defaults to http.StatusOK.
+	ExpectedStatus int
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+func (c HTTPCheck) Name() string {
+	return "http " + c.URL
+}
+
+func (c HTTPCheck) Check(ctx context.Context, app *unstructured.Unstructured) error {
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	expected := c.ExpectedStatus
+	if expected == 0 {
+		expected = http.StatusOK
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL, nil)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != expected {
+		return microerror.Maskf(checkFailedError, "expected status %d, got %d", expected, res.StatusCode)
+	}
+
+	return nil
+}
+
+// JobCheck passes when the Job with the given name has completed.
+type JobCheck struct {
+	// Jobs is a batch/v1 Job client scoped to the Job's namespace.
+	Jobs ResourceInterface
+	Job  string
+}
+
+func (c JobCheck) Name() string {
+	return "job " + c.Job
+}
+
+func (c JobCheck) Check(ctx context.Context, app *unstructured.Unstructured) error {
+	job, err := c.Jobs.Get(ctx, c.Job, metav1.GetOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	conditions, _, err := unstructured.NestedSlice(job.Object, "status", "conditions")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, item := range conditions {
+		condition, ok := item.(map[string]interface{})
+		if !ok || condition["status"] != "True" {
+			continue
+		}
+
+		switch condition["type"] {
+		case "Complete":
+			return nil
+		case "Failed":
+			return microerror.Maskf(checkFailedError, "job failed: %v", condition["message"])
+		}
+	}
+
+	return microerror.Maskf(checkFailedError, "job has not completed yet")
+}
+
+// PrometheusQuerier runs instant PromQL queries returning a single value.
+type PrometheusQuerier interface {
+	Query(ctx context.Context, query string) (float64, error)
+}
+
+// PromQLCheck passes when Query returns a non-zero value, e.g.
+// `up{job="my-app"} == 1`.
+type PromQLCheck struct {
+	Querier PrometheusQuerier
+	Query   string
+}
+
+func (c PromQLCheck) Name() string {
+	return "promql " + c.Query
+}
+
+func (c PromQLCheck) Check(ctx context.Context, app *unstructured.Unstructured) error {
+	v, err := c.Querier.Query(ctx, c.Query)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if v == 0 {
+		return microerror.Maskf(checkFailedError, "query returned zero")
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package argoappclient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	defaultWaitInterval = 5 * time.Second
+	defaultWaitTimeout  = 10 * time.Minute
+)
+
+type WaitOptions struct {
+	// Timeout defaults to 10 minutes.
+	Timeout time.Duration
+	// Interval is the polling interval. Defaults to 5 seconds.
+	Interval time.Duration
+	// Progress is called with every observed Application. It is optional.
+	Progress func(app *unstructured.Unstructured)
+	// Checks is optional. When set, WaitForSynced and WaitForHealthy wait
+	// for the Application to be both Synced and Healthy and then run the
+	// checks registered for it.
+	Checks *PostSyncChecks
+}
+
+// WaitTimeoutError is returned by the waiters when the Application does not
+// reach the desired status in time. It carries the last observed status to
+// help debugging.
+type WaitTimeoutError struct {
+	Application string
+	Sync        string
+	Health      string
+	// Conditions are the last observed status.conditions formatted as
+	// "<type>: <message>".
+	Conditions []string
+}
+
+func (e *WaitTimeoutError) Error() string {
+	msg := fmt.Sprintf("timed out waiting for Application %#q, last observed sync status %#q and health status %#q", e.Application, e.Sync, e.Health)
+	if len(e.Conditions) > 0 {
+		msg += ", conditions: " + strings.Join(e.Conditions, "; ")
+	}
+
+	return msg
+}
+
+// IsWaitTimeout asserts *WaitTimeoutError.
+func IsWaitTimeout(err error) bool {
+	_, ok := microerror.Cause(err).(*WaitTimeoutError)
+	return ok
+}
+
+// WaitForSynced polls the Application with the given name until its sync
+// status is Synced, and its health status Healthy when WaitOptions.Checks is
+// set, and returns it. It returns a *WaitTimeoutError when the timeout expires
+// and an error matched by IsVerificationFailed when the WaitOptions.Checks
+// fail.
+func WaitForSynced(ctx context.Context, applications ResourceInterface, name string, opts WaitOptions) (*unstructured.Unstructured, error) {
+	app, err := waitFor(ctx, applications, name, opts, withChecks(opts, IsSynced))
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	err = runChecks(ctx, opts, app)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return app, nil
+}
+
+// WaitForHealthy polls the Application with the given name until its health
+// status is Healthy, and its sync status Synced when WaitOptions.Checks is
+// set, and returns it. It returns a *WaitTimeoutError when the timeout expires
+// and an error matched by IsVerificationFailed when the WaitOptions.Checks
+// fail.
+func WaitForHealthy(ctx context.Context, applications ResourceInterface, name string, opts WaitOptions) (*unstructured.Unstructured, error) {
+	app, err := waitFor(ctx, applications, name, opts, withChecks(opts, IsHealthy))
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	err = runChecks(ctx, opts, app)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return app, nil
+}
+
+// withChecks returns the condition to wait for before running the
+// WaitOptions.Checks, which expect the Application to be Synced and Healthy.
+func withChecks(opts WaitOptions, done func(app *unstructured.Unstructured) bool) func(app *unstructured.Unstructured) bool {
+	if opts.Checks == nil {
+		return done
+	}
+
+	return func(app *unstructured.Unstructured) bool {
+		return IsSynced(app) && IsHealthy(app)
+	}
+}
+
+func runChecks(ctx context.Context, opts WaitOptions, app *unstructured.Unstructured) error {
+	if opts.Checks == nil {
+		return nil
+	}
+
+	err := opts.Checks.Run(ctx, app)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func waitFor(ctx context.Context, applications ResourceInterface, name string, opts WaitOptions, done func(app *unstructured.Unstructured) bool) (*unstructured.Unstructured, error) {
+	if opts.Timeout == 0 {
+		opts.Timeout = defaultWaitTimeout
+	}
+	if opts.Interval == 0 {
+		opts.Interval = defaultWaitInterval
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	var last *unstructured.Unstructured
+	for {
+		app, err := applications.Get(ctx, name, metav1.GetOptions{})
+		if err != nil && ctx.Err() == nil {
+			return nil, microerror.Mask(err)
+		} else if err == nil {
+			last = app
+			if opts.Progress != nil {
+				opts.Progress(app)
+			}
+			if done(app) {
+				return app, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, microerror.Mask(newWaitTimeoutError(name, last))
+		case <-ticker.C:
+		}
+	}
+}
+
+func newWaitTimeoutError(name string, app *unstructured.Unstructured) *WaitTimeoutError {
+	e := &WaitTimeoutError{
+		Application: name,
+	}
+	if app == nil {
+		return e
+	}
+
+	e.Sync, _, _ = unstructured.NestedString(app.Object, "status", "sync", "status")
+	e.Health, _, _ = unstructured.NestedString(app.Object, "status", "health", "status")
+
+	conditions, _, _ := unstructured.NestedSlice(app.Object, "status", "conditions")
+	for _, item := range conditions {
+		condition, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		e.Conditions = append(e.Conditions, fmt.Sprintf("%v: %v", condition["type"], condition["message"]))
+	}
+
+	return e
+}
@@ -0,0 +1,169 @@
+package argoappclient
+
+import (
+	"context"
+
+	"github.com/giantswarm/microerror"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// ApplicationEventType is the kind of status transition of an
+// ApplicationEvent.
+type ApplicationEventType string
+
+const (
+	// ApplicationEventSyncStatus is a change of the sync status, e.g. from
+	// "OutOfSync" to "Synced".
+	ApplicationEventSyncStatus ApplicationEventType = "SyncStatus"
+	// ApplicationEventHealthStatus is a change of the health status, e.g.
+	// from "Progressing" to "Healthy".
+	ApplicationEventHealthStatus ApplicationEventType = "HealthStatus"
+	// ApplicationEventOperationPhase is a change of the operation phase,
+	// e.g. from "Running" to "Succeeded".
+	ApplicationEventOperationPhase ApplicationEventType = "OperationPhase"
+	// ApplicationEventDeleted is the deletion of the Application.
+	ApplicationEventDeleted ApplicationEventType = "Deleted"
+)
+
+// ApplicationEvent is a status transition of an Application observed with
+// WatchApplication.
+type ApplicationEvent struct {
+	Type ApplicationEventType
+	// From is the previous status or phase. It is empty for the first
+	// observed status.
+	From string
+	// To is the new status or phase.
+	To string
+	// Message is the health or operation message.
+	Message string
+	// Revision is the synced revision with ApplicationEventSyncStatus and
+	// the operation revision with ApplicationEventOperationPhase.
+	Revision string
+	// Application is the observed Application.
+	Application *unstructured.Unstructured
+}
+
+// WatchApplication streams the status transitions of the Application with
+// the given name, e.g. to show the deployment progress to users. The current
+// status is sent as transitions from empty values first. The returned channel
+// is closed when ctx is done, the Application is deleted or the underlying
+// watch ends.
+func WatchApplication(ctx context.Context, applications ResourceInterface, name string) (<-chan ApplicationEvent, error) {
+	w, err := applications.Watch(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", name).String(),
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	events := make(chan ApplicationEvent)
+	go func() {
+		defer close(events)
+		defer w.Stop()
+
+		var last applicationState
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-w.ResultChan():
+				if !ok {
+					return
+				}
+				obj, ok := e.Object.(*unstructured.Unstructured)
+				if !ok || obj.GetName() != name {
+					continue
+				}
+
+				var transitions []ApplicationEvent
+				switch e.Type {
+				case watch.Added, watch.Modified:
+					state := newApplicationState(obj)
+					transitions = last.transitions(state, obj)
+					last = state
+				case watch.Deleted:
+					transitions = []ApplicationEvent{{Type: ApplicationEventDeleted, Application: obj}}
+				default:
+					continue
+				}
+
+				for _, t := range transitions {
+					select {
+					case events <- t:
+					case <-ctx.Done():
+						return
+					}
+				}
+				if e.Type == watch.Deleted {
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+type applicationState struct {
+	sync             string
+	syncRevision     string
+	health           string
+	healthMessage    string
+	phase            string
+	phaseMessage     string
+	phaseRevision    string
+	operationStarted string
+}
+
+func newApplicationState(app *unstructured.Unstructured) applicationState {
+	var s applicationState
+	s.sync, _, _ = unstructured.NestedString(app.Object, "status", "sync", "status")
+	s.syncRevision, _, _ = unstructured.NestedString(app.Object, "status", "sync", "revision")
+	s.health, _, _ = unstructured.NestedString(app.Object, "status", "health", "status")
+	s.healthMessage, _, _ = unstructured.NestedString(app.Object, "status", "health", "message")
+	s.phase, _, _ = unstructured.NestedString(app.Object, "status", "operationState", "phase")
+	s.phaseMessage, _, _ = unstructured.NestedString(app.Object, "status", "operationState", "message")
+	s.phaseRevision, _, _ = unstructured.NestedString(app.Object, "status", "operationState", "syncResult", "revision")
+	s.operationStarted, _, _ = unstructured.NestedString(app.Object, "status", "operationState", "startedAt")
+
+	return s
+}
+
+// transitions returns the events of the changes from s to next. A new
+// operation with the same phase as the previous one is a transition too.
+func (s applicationState) transitions(next applicationState, app *unstructured.Unstructured) []ApplicationEvent {
+	var events []ApplicationEvent
+	if next.sync != s.sync {
+		events = append(events, ApplicationEvent{
+			Type:        ApplicationEventSyncStatus,
+			From:        s.sync,
+			To:          next.sync,
+			Revision:    next.syncRevision,
+			Application: app,
+		})
+	}
+	if next.health != s.health {
+		events = append(events, ApplicationEvent{
+			Type:        ApplicationEventHealthStatus,
+			From:        s.health,
+			To:          next.health,
+			Message:     next.healthMessage,
+			Application: app,
+		})
+	}
+	if next.phase != s.phase || next.operationStarted != s.operationStarted {
+		events = append(events, ApplicationEvent{
+			Type:        ApplicationEventOperationPhase,
+			From:        s.phase,
+			To:          next.phase,
+			Message:     next.phaseMessage,
+			Revision:    next.phaseRevision,
+			Application: app,
+		})
+	}
+
+	return events
+}
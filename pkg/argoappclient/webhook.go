@@ -0,0 +1,231 @@
+package argoappclient
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/giantswarm/argoapp/pkg/argoapp"
+)
+
+// maxWebhookBodySize is the maximum size of the webhook requests read by
+// WebhookRelay. It is the size GitHub caps the payloads at, larger requests
+// are rejected before their signature is verified.
+const maxWebhookBodySize = 25 << 20
+
+type WebhookRelayConfig struct {
+	Applications ResourceInterface
+
+	// RepoURL is the URL of the config repository. Defaults to
+	// argoapp.Defaults.ConfigRepoURL.
+	RepoURL string
+	// Secret is optional. When set, GitHub requests must be signed with it
+	// (X-Hub-Signature-256) and GitLab requests must carry it as the
+	// X-Gitlab-Token header.
+	Secret string
+}
+
+// WebhookRelay is an http.Handler receiving GitHub and GitLab push webhooks
+// for the config repository. It requests a refresh only of the Applications
+// affected by the pushed changes, as declared with the
+// argocd.argoproj.io/manifest-generate-paths annotation, so they are synced
+// without waiting for the Argo CD polling interval. Requests larger than
+// 25 MiB are rejected.
+type WebhookRelay struct {
+	applications ResourceInterface
+	repoURL      string
+	secret       string
+}
+
+func NewWebhookRelay(config WebhookRelayConfig) (*WebhookRelay, error) {
+	if config.Applications == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Applications must not be empty", config)
+	}
+
+	if config.RepoURL == "" {
+		config.RepoURL = argoapp.GetDefaults().ConfigRepoURL
+	}
+
+	r := &WebhookRelay{
+		applications: config.Applications,
+		repoURL:      normalizeRepoURL(config.RepoURL),
+		secret:       config.Secret,
+	}
+
+	return r, nil
+}
+
+// pushEvent is the part of the push event payload common to GitHub and
+// GitLab.
+type pushEvent struct {
+	Ref        string `json:"ref"`
+	Repository struct {
+		// GitHub.
+		CloneURL string `json:"clone_url"`
+		HTMLURL  string `json:"html_url"`
+		// GitLab.
+		GitHTTPURL string `json:"git_http_url"`
+		Homepage   string `json:"homepage"`
+	} `json:"repository"`
+	Commits []struct {
+		Added    []string `json:"added"`
+		Modified []string `json:"modified"`
+		Removed  []string `json:"removed"`
+	} `json:"commits"`
+}
+
+func (r *WebhookRelay) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxWebhookBodySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !r.authorized(req, body) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	event := req.Header.Get("X-GitHub-Event")
+	if event == "" {
+		event = req.Header.Get("X-Gitlab-Event")
+	}
+	if event != "push" && event != "Push Hook" && event != "Tag Push Hook" {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	var payload pushEvent
+	err = json.Unmarshal(body, &payload)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = r.refresh(req.Context(), payload)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (r *WebhookRelay) authorized(req *http.Request, body []byte) bool {
+	if r.secret == "" {
+		return true
+	}
+
+	if token := req.Header.Get("X-Gitlab-Token"); token != "" {
+		return hmac.Equal([]byte(token), []byte(r.secret))
+	}
+
+	signature := strings.TrimPrefix(req.Header.Get("X-Hub-Signature-256"), "sha256=")
+	mac := hmac.New(sha256.New, []byte(r.secret))
+	mac.Write(body)
+
+	return hmac.Equal([]byte(signature), []byte(hex.EncodeToString(mac.Sum(nil))))
+}
+
+func (r *WebhookRelay) refresh(ctx context.Context, event pushEvent) error {
+	matched := false
+	for _, u := range []string{event.Repository.CloneURL, event.Repository.HTMLURL, event.Repository.GitHTTPURL, event.Repository.Homepage} {
+		if u != "" && normalizeRepoURL(u) == r.repoURL {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return nil
+	}
+
+	revision := strings.TrimPrefix(strings.TrimPrefix(event.Ref, "refs/heads/"), "refs/tags/")
+
+	var changed []string
+	for _, c := range event.Commits {
+		changed = append(changed, c.Added...)
+		changed = append(changed, c.Modified...)
+		changed = append(changed, c.Removed...)
+	}
+
+	list, err := r.applications.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, app := range list.Items {
+		if !affected(app, r.repoURL, revision, changed) {
+			continue
+		}
+
+		err = RequestRefresh(ctx, r.applications, app.GetName(), RefreshTypeNormal)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// affected returns true when the Application sources the repository at the
+// revision and any of the changed files is in its manifest generate paths.
+// Applications without the manifest generate paths annotation are affected
+// by any change.
+func affected(app unstructured.Unstructured, repoURL, revision string, changed []string) bool {
+	appRepoURL, _, _ := unstructured.NestedString(app.Object, "spec", "source", "repoURL")
+	if normalizeRepoURL(appRepoURL) != repoURL {
+		return false
+	}
+
+	targetRevision, _, _ := unstructured.NestedString(app.Object, "spec", "source", "targetRevision")
+	if targetRevision != revision {
+		return false
+	}
+
+	paths, ok := app.GetAnnotations()[argoapp.ManifestGeneratePathsAnnotation]
+	if !ok {
+		return true
+	}
+
+	sourcePath, _, _ := unstructured.NestedString(app.Object, "spec", "source", "path")
+
+	for _, p := range strings.Split(paths, ";") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		// Absolute paths are relative to the repository root, others to
+		// the Application source path.
+		if strings.HasPrefix(p, "/") {
+			p = path.Clean(strings.TrimPrefix(p, "/"))
+		} else {
+			p = path.Join(sourcePath, p)
+		}
+
+		for _, f := range changed {
+			if p == "." || f == p || strings.HasPrefix(f, p+"/") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func normalizeRepoURL(u string) string {
+	u = strings.ToLower(strings.TrimSpace(u))
+	u = strings.TrimSuffix(u, "/")
+	u = strings.TrimSuffix(u, ".git")
+
+	return u
+}
@@ -0,0 +1,153 @@
+// Package backstage emits Backstage catalog entities for the Applications
+// generated by package argoapp, so the app fleet appears in the developer
+// portal.
+package backstage
+
+import (
+	"bytes"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/giantswarm/argoapp/pkg/tenancy"
+)
+
+const (
+	// ArgoCDAppNameAnnotation is read by the Backstage Argo CD plugin to
+	// show the Application state on the Component page.
+	ArgoCDAppNameAnnotation = "argocd/app-name"
+
+	defaultLifecycle = "production"
+	defaultType      = "service"
+)
+
+type Config struct {
+	// ArgoURL is the URL of the Argo CD UI, e.g.
+	// "https://argocd.example.com". The Components link to their
+	// Application in it.
+	ArgoURL string
+	// OwnerLabel is the Application label holding the owning team.
+	// Defaults to tenancy.TenantLabel.
+	OwnerLabel string
+	// DefaultOwner is the owner of the Applications without the OwnerLabel.
+	// Applications without an owner are rejected when it is empty.
+	DefaultOwner string
+	// System is the Backstage system of the Components. Defaults to the
+	// Argo CD project of the Application.
+	System string
+	// Lifecycle defaults to "production".
+	Lifecycle string
+	// Type defaults to "service".
+	Type string
+}
+
+// NewComponent returns the Backstage Component entity of the Application.
+// The Application info entries with URL values are added as links.
+func NewComponent(app *unstructured.Unstructured, config Config) (map[string]interface{}, error) {
+	if config.ArgoURL == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ArgoURL must not be empty", config)
+	}
+	argoURL, err := url.Parse(config.ArgoURL)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ArgoURL %#q is invalid: %s", config, config.ArgoURL, err)
+	}
+	if config.OwnerLabel == "" {
+		config.OwnerLabel = tenancy.TenantLabel
+	}
+	if config.Lifecycle == "" {
+		config.Lifecycle = defaultLifecycle
+	}
+	if config.Type == "" {
+		config.Type = defaultType
+	}
+
+	owner := app.GetLabels()[config.OwnerLabel]
+	if owner == "" {
+		owner = config.DefaultOwner
+	}
+	if owner == "" {
+		return nil, microerror.Maskf(invalidConfigError, "Application %#q has no %#q label and %T.DefaultOwner is empty", app.GetName(), config.OwnerLabel, config)
+	}
+	system := config.System
+	if system == "" {
+		system, _, _ = unstructured.NestedString(app.Object, "spec", "project")
+	}
+
+	appURL := *argoURL
+	appURL.Path = path.Join(appURL.Path, "applications", app.GetNamespace(), app.GetName())
+
+	links := []interface{}{
+		map[string]interface{}{
+			"url":   appURL.String(),
+			"title": "Argo CD",
+		},
+	}
+	info, _, _ := unstructured.NestedSlice(app.Object, "spec", "info")
+	for _, i := range info {
+		entry, ok := i.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := entry["name"].(string)
+		value, _ := entry["value"].(string)
+		if strings.HasPrefix(value, "https://") || strings.HasPrefix(value, "http://") {
+			links = append(links, map[string]interface{}{
+				"url":   value,
+				"title": name,
+			})
+		}
+	}
+
+	spec := map[string]interface{}{
+		"type":      config.Type,
+		"lifecycle": config.Lifecycle,
+		"owner":     owner,
+	}
+	if system != "" {
+		spec["system"] = system
+	}
+
+	component := map[string]interface{}{
+		"apiVersion": "backstage.io/v1alpha1",
+		"kind":       "Component",
+		"metadata": map[string]interface{}{
+			"name": app.GetName(),
+			"annotations": map[string]interface{}{
+				ArgoCDAppNameAnnotation: app.GetName(),
+			},
+			"links": links,
+			"tags":  []interface{}{"argocd"},
+		},
+		"spec": spec,
+	}
+
+	return component, nil
+}
+
+// CatalogInfo returns the catalog-info.yaml with the Components of all the
+// Applications as YAML documents.
+func CatalogInfo(apps []*unstructured.Unstructured, config Config) ([]byte, error) {
+	var b bytes.Buffer
+	for i, app := range apps {
+		component, err := NewComponent(app, config)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		data, err := yaml.Marshal(component)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		if i > 0 {
+			b.WriteString("---\n")
+		}
+		b.Write(data)
+	}
+
+	return b.Bytes(), nil
+}
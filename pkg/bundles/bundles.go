@@ -0,0 +1,199 @@
+// Package bundles defines named sets of apps, e.g. the default apps of a
+// provider, shared by the cluster bootstrap tooling, tests and operators. The
+// app versions of all the bundles are pinned in a single versions file.
+package bundles
+
+import (
+	"bytes"
+	"embed"
+	"io/fs"
+	"path"
+	"sort"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/giantswarm/argoapp/pkg/argoapp"
+)
+
+const (
+	// AWSDefaultApps are the default apps of AWS clusters.
+	AWSDefaultApps = "aws-default-apps"
+	// AzureDefaultApps are the default apps of Azure clusters.
+	AzureDefaultApps = "azure-default-apps"
+	// ObservabilityBundle are the monitoring and logging apps.
+	ObservabilityBundle = "observability-bundle"
+)
+
+// versionsFile is the file pinning the app versions.
+const versionsFile = "versions.yaml"
+
+//go:embed definitions
+var definitions embed.FS
+
+// Definition is a bundle definition file.
+type Definition struct {
+	Name string `json:"name"`
+	// Includes are the names of the bundles whose apps are included.
+	Includes []string `json:"includes,omitempty"`
+	Apps     []App    `json:"apps"`
+}
+
+// App is an app of a bundle. Its version is pinned in the versions file.
+type App struct {
+	App       string `json:"app"`
+	Catalog   string `json:"catalog"`
+	Namespace string `json:"namespace"`
+}
+
+type ResolverConfig struct {
+	// Definitions is optional. It holds the bundle definitions, one YAML
+	// file per bundle, and the versions.yaml file mapping the app names to
+	// their versions. Defaults to the bundles defined in this package.
+	Definitions fs.FS
+}
+
+// Resolver resolves the bundles into ApplicationConfigs.
+type Resolver struct {
+	definitions map[string]Definition
+	versions    map[string]string
+}
+
+func NewResolver(config ResolverConfig) (*Resolver, error) {
+	if config.Definitions == nil {
+		sub, err := fs.Sub(definitions, "definitions")
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		config.Definitions = sub
+	}
+
+	r := &Resolver{
+		definitions: map[string]Definition{},
+		versions:    map[string]string{},
+	}
+
+	files, err := fs.Glob(config.Definitions, "*.yaml")
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	for _, f := range files {
+		data, err := fs.ReadFile(config.Definitions, f)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		if f == versionsFile {
+			err = yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096).Decode(&r.versions)
+			if err != nil {
+				return nil, microerror.Maskf(invalidConfigError, "%s: %s", f, err)
+			}
+			continue
+		}
+
+		var d Definition
+		err = yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096).Decode(&d)
+		if err != nil {
+			return nil, microerror.Maskf(invalidConfigError, "%s: %s", f, err)
+		}
+		if d.Name != trimExt(f) {
+			return nil, microerror.Maskf(invalidConfigError, "%s: bundle name %#q does not match the file name", f, d.Name)
+		}
+		r.definitions[d.Name] = d
+	}
+
+	for _, d := range r.definitions {
+		for _, name := range d.Includes {
+			if _, ok := r.definitions[name]; !ok {
+				return nil, microerror.Maskf(invalidConfigError, "bundle %#q includes undefined bundle %#q", d.Name, name)
+			}
+		}
+		for _, a := range d.Apps {
+			if _, ok := r.versions[a.App]; !ok {
+				return nil, microerror.Maskf(invalidConfigError, "app %#q of bundle %#q has no version in %s", a.App, d.Name, versionsFile)
+			}
+		}
+	}
+	for name := range r.definitions {
+		_, err := r.apps(name, map[string]bool{})
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	return r, nil
+}
+
+// Names returns the sorted names of the defined bundles.
+func (r *Resolver) Names() []string {
+	var names []string
+	for name := range r.definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Resolve returns the ApplicationConfigs of the apps of the bundle and its
+// included bundles with the pinned versions. Each app is included once, the
+// apps of the bundle itself take precedence over the included ones. The
+// configs are named after the apps and have no ConfigRef, so they are
+// usually completed with argoapp.NewApplicationCollectionWithDefaults. It
+// returns an error matched by IsNotFound for undefined bundles.
+func (r *Resolver) Resolve(name string) ([]argoapp.ApplicationConfig, error) {
+	if _, ok := r.definitions[name]; !ok {
+		return nil, microerror.Maskf(notFoundError, "bundle %#q", name)
+	}
+
+	apps, err := r.apps(name, map[string]bool{})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var configs []argoapp.ApplicationConfig
+	seen := map[string]bool{}
+	for _, a := range apps {
+		if seen[a.App] {
+			continue
+		}
+		seen[a.App] = true
+
+		configs = append(configs, argoapp.ApplicationConfig{
+			Name:                    a.App,
+			AppName:                 a.App,
+			AppVersion:              r.versions[a.App],
+			AppCatalog:              a.Catalog,
+			AppDestinationNamespace: a.Namespace,
+		})
+	}
+
+	return configs, nil
+}
+
+// apps returns the apps of the bundle followed by the apps of the included
+// bundles.
+func (r *Resolver) apps(name string, visiting map[string]bool) ([]App, error) {
+	if visiting[name] {
+		return nil, microerror.Maskf(invalidConfigError, "bundle %#q is included in a cycle", name)
+	}
+	visiting[name] = true
+	defer delete(visiting, name)
+
+	d := r.definitions[name]
+	apps := append([]App{}, d.Apps...)
+	for _, include := range d.Includes {
+		included, err := r.apps(include, visiting)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		apps = append(apps, included...)
+	}
+
+	return apps, nil
+}
+
+func trimExt(f string) string {
+	return f[:len(f)-len(path.Ext(f))]
+}
@@ -0,0 +1,226 @@
+// Package capi installs a bundle of default apps into Cluster API workload
+// clusters. Argo CD Applications are generated from the bundle when a Cluster
+// is created and pruned when it is deleted.
+package capi
+
+import (
+	"context"
+	"net"
+	"strconv"
+
+	"github.com/giantswarm/microerror"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/giantswarm/argoapp/pkg/argoapp"
+	"github.com/giantswarm/argoapp/pkg/argoappclient"
+)
+
+const (
+	// ClusterNameLabel is set on the Applications generated for a Cluster
+	// to the Cluster name.
+	ClusterNameLabel = "argoapp.giantswarm.io/cluster-name"
+	// ClusterNamespaceLabel is set on the Applications generated for a
+	// Cluster to the Cluster namespace.
+	ClusterNamespaceLabel = "argoapp.giantswarm.io/cluster-namespace"
+)
+
+// ClusterResource is the Cluster API Cluster resource.
+var ClusterResource = schema.GroupVersionResource{
+	Group:    "cluster.x-k8s.io",
+	Version:  "v1beta1",
+	Resource: "clusters",
+}
+
+// DestinationResolver returns the Argo CD destination of the Applications of
+// the Cluster. Only one of server and name is set.
+type DestinationResolver interface {
+	Destination(ctx context.Context, cluster *unstructured.Unstructured) (server, name string, err error)
+}
+
+// ClusterNameResolver resolves the destination to the cluster registered in
+// Argo CD with the Cluster name.
+type ClusterNameResolver struct{}
+
+func (ClusterNameResolver) Destination(ctx context.Context, cluster *unstructured.Unstructured) (string, string, error) {
+	return "", cluster.GetName(), nil
+}
+
+// ControlPlaneEndpointResolver resolves the destination to the Cluster
+// spec.controlPlaneEndpoint API server URL.
+type ControlPlaneEndpointResolver struct{}
+
+func (ControlPlaneEndpointResolver) Destination(ctx context.Context, cluster *unstructured.Unstructured) (string, string, error) {
+	host, _, _ := unstructured.NestedString(cluster.Object, "spec", "controlPlaneEndpoint", "host")
+	port, _, _ := unstructured.NestedInt64(cluster.Object, "spec", "controlPlaneEndpoint", "port")
+	if host == "" || port == 0 {
+		return "", "", microerror.Maskf(endpointNotReadyError, "Cluster %#q has no control plane endpoint", cluster.GetName())
+	}
+
+	return "https://" + net.JoinHostPort(host, strconv.FormatInt(port, 10)), "", nil
+}
+
+type ReconcilerConfig struct {
+	// Clusters is scoped to ClusterNamespace.
+	Clusters     argoappclient.ResourceInterface
+	Applications argoappclient.ResourceInterface
+
+	// ClusterNamespace is the namespace of the reconciled Clusters.
+	ClusterNamespace string
+	// Bundle are the apps installed into every Cluster. The Application of
+	// each is named <cluster>-<Name>, shortened with argoapp.GenerateName
+	// when needed. The destination fields are set by the Destination
+	// resolver.
+	Bundle []argoapp.ApplicationConfig
+	// Destination defaults to ClusterNameResolver.
+	Destination DestinationResolver
+}
+
+// Reconciler generates the Applications of the Bundle for each Cluster and
+// deletes the ones of deleted Clusters or removed from the Bundle. It does not
+// watch the resources itself, Reconcile is meant to be called by a
+// controller, e.g. built with controller-runtime, for every Cluster event.
+type Reconciler struct {
+	clusters         argoappclient.ResourceInterface
+	applications     argoappclient.ResourceInterface
+	clusterNamespace string
+	bundle           []argoapp.ApplicationConfig
+	destination      DestinationResolver
+}
+
+func NewReconciler(config ReconcilerConfig) (*Reconciler, error) {
+	if config.Clusters == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Clusters must not be empty", config)
+	}
+	if config.Applications == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Applications must not be empty", config)
+	}
+	if config.ClusterNamespace == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ClusterNamespace must not be empty", config)
+	}
+	names := map[string]bool{}
+	for i, c := range config.Bundle {
+		if c.Name == "" {
+			return nil, microerror.Maskf(invalidConfigError, "%T.Bundle[%d].Name must not be empty", config, i)
+		}
+		if names[c.Name] {
+			return nil, microerror.Maskf(invalidConfigError, "%T.Bundle[%d].Name %#q is not unique", config, i, c.Name)
+		}
+		names[c.Name] = true
+	}
+	if config.Destination == nil {
+		config.Destination = ClusterNameResolver{}
+	}
+
+	r := &Reconciler{
+		clusters:         config.Clusters,
+		applications:     config.Applications,
+		clusterNamespace: config.ClusterNamespace,
+		bundle:           config.Bundle,
+		destination:      config.Destination,
+	}
+
+	return r, nil
+}
+
+// Reconcile reconciles the Cluster with the given name. The Applications of a
+// missing or deleted Cluster are deleted. An error matched by
+// IsEndpointNotReady is returned while the destination of a new Cluster can
+// not be resolved yet, so the Cluster is requeued.
+func (r *Reconciler) Reconcile(ctx context.Context, name string) error {
+	cluster, err := r.clusters.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return microerror.Mask(r.prune(ctx, name, nil))
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if cluster.GetDeletionTimestamp() != nil {
+		return microerror.Mask(r.prune(ctx, name, nil))
+	}
+
+	server, destinationName, err := r.destination.Destination(ctx, cluster)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// Frozen Applications are skipped so the others are applied, the error
+	// is returned after.
+	var frozenErr error
+	keep := map[string]bool{}
+	for _, c := range r.bundle {
+		c.Name = argoapp.GenerateName(c.Name, name, "")
+		c.AppDestinationServer = server
+		c.AppDestinationName = destinationName
+
+		app, err := argoapp.NewApplication(c)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		labels := app.GetLabels()
+		labels[ClusterNameLabel] = name
+		labels[ClusterNamespaceLabel] = r.clusterNamespace
+		app.SetLabels(labels)
+
+		err = argoapp.SealIntegrity(app)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		_, err = argoappclient.ApplyApplication(ctx, r.applications, app)
+		if argoappclient.IsFrozen(err) {
+			frozenErr = err
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		keep[app.GetName()] = true
+	}
+
+	err = r.prune(ctx, name, keep)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return microerror.Mask(frozenErr)
+}
+
+// prune deletes the Applications of the Cluster with the given name which are
+// not in keep.
+func (r *Reconciler) prune(ctx context.Context, name string, keep map[string]bool) error {
+	selector := labels.SelectorFromSet(labels.Set{
+		ClusterNameLabel:      name,
+		ClusterNamespaceLabel: r.clusterNamespace,
+	})
+
+	list, err := r.applications.List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// Protected Applications are skipped so the others are pruned, the
+	// error is returned after.
+	var protectedErr error
+	for _, app := range list.Items {
+		if keep[app.GetName()] {
+			continue
+		}
+
+		err = argoappclient.DeleteApplication(ctx, r.applications, app.GetName(), argoappclient.DeleteOptions{})
+		if argoappclient.IsProtected(err) {
+			protectedErr = err
+			continue
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+	if protectedErr != nil {
+		return microerror.Mask(protectedErr)
+	}
+
+	return nil
+}
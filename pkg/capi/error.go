@@ -0,0 +1,21 @@
+package capi
+
+import "github.com/giantswarm/microerror"
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var endpointNotReadyError = &microerror.Error{
+	Kind: "endpointNotReadyError",
+}
+
+// IsEndpointNotReady asserts endpointNotReadyError.
+func IsEndpointNotReady(err error) bool {
+	return microerror.Cause(err) == endpointNotReadyError
+}
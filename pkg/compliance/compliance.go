@@ -0,0 +1,244 @@
+// Package compliance reports how the Applications of a fleet follow the
+// recommended Application settings checked by argoapp.Lint.
+package compliance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/giantswarm/argoapp/pkg/argoapp"
+	"github.com/giantswarm/argoapp/pkg/argoappclient"
+	"github.com/giantswarm/argoapp/pkg/tenancy"
+)
+
+// Profile is the policy profile the fleet is checked against.
+type Profile struct {
+	Name string `json:"name"`
+	// Rules are the argoapp.LintRules an Application must pass to be
+	// compliant. Defaults to all of them.
+	Rules []string `json:"rules,omitempty"`
+	// TeamLabel is the Application label holding the owning team. Defaults
+	// to tenancy.TenantLabel.
+	TeamLabel string `json:"teamLabel,omitempty"`
+}
+
+// Summary counts the compliant Applications and the violations per rule.
+type Summary struct {
+	Applications int            `json:"applications"`
+	Compliant    int            `json:"compliant"`
+	Violations   map[string]int `json:"violations"`
+}
+
+// Result is the compliance of a single Application.
+type Result struct {
+	Installation string   `json:"installation"`
+	Team         string   `json:"team"`
+	Application  string   `json:"application"`
+	Violations   []string `json:"violations,omitempty"`
+}
+
+// Report is the fleet compliance report.
+type Report struct {
+	Profile     string    `json:"profile"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	Total       Summary   `json:"total"`
+	// Installations are the summaries per installation.
+	Installations map[string]Summary `json:"installations"`
+	// Teams are the summaries per team. Applications without the team
+	// label are counted with the empty team name.
+	Teams   map[string]Summary `json:"teams"`
+	Results []Result           `json:"results"`
+}
+
+// NewReport checks the Applications of each installation, keyed by the
+// installation name, against the profile.
+func NewReport(profile Profile, installations map[string][]unstructured.Unstructured, now time.Time) (Report, error) {
+	rules, err := profile.rules()
+	if err != nil {
+		return Report{}, microerror.Mask(err)
+	}
+	teamLabel := profile.TeamLabel
+	if teamLabel == "" {
+		teamLabel = tenancy.TenantLabel
+	}
+
+	r := Report{
+		Profile:       profile.Name,
+		GeneratedAt:   now.UTC(),
+		Total:         newSummary(),
+		Installations: map[string]Summary{},
+		Teams:         map[string]Summary{},
+		Results:       []Result{},
+	}
+
+	for installation, apps := range installations {
+		for _, app := range apps {
+			result := Result{
+				Installation: installation,
+				Team:         app.GetLabels()[teamLabel],
+				Application:  app.GetName(),
+			}
+			for _, f := range argoapp.Lint(&app) {
+				if rules[f.Rule] {
+					result.Violations = append(result.Violations, f.Rule)
+				}
+			}
+
+			r.Results = append(r.Results, result)
+			r.Total = r.Total.add(result)
+			r.Installations[installation] = summary(r.Installations, installation).add(result)
+			r.Teams[result.Team] = summary(r.Teams, result.Team).add(result)
+		}
+	}
+
+	sort.Slice(r.Results, func(i, j int) bool {
+		a, b := r.Results[i], r.Results[j]
+		if a.Installation != b.Installation {
+			return a.Installation < b.Installation
+		}
+		return a.Application < b.Application
+	})
+
+	return r, nil
+}
+
+// Collect lists the Applications of each installation, keyed by the
+// installation name, and returns their compliance report.
+func Collect(ctx context.Context, profile Profile, installations map[string]argoappclient.ResourceInterface) (Report, error) {
+	fleet := map[string][]unstructured.Unstructured{}
+	for installation, applications := range installations {
+		list, err := applications.List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return Report{}, microerror.Mask(err)
+		}
+		fleet[installation] = list.Items
+	}
+
+	r, err := NewReport(profile, fleet, time.Now())
+	if err != nil {
+		return Report{}, microerror.Mask(err)
+	}
+
+	return r, nil
+}
+
+// JSON returns the indented JSON report.
+func (r Report) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return data, nil
+}
+
+// Markdown returns the summaries of the report as Markdown tables.
+func (r Report) Markdown() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Compliance report: %s\n\n", r.Profile)
+	fmt.Fprintf(&b, "Generated at %s. %d of %d Applications are compliant (%s).\n", r.GeneratedAt.Format(time.RFC3339), r.Total.Compliant, r.Total.Applications, percent(r.Total))
+
+	writeSummaries(&b, "Installation", r.Installations)
+	writeSummaries(&b, "Team", r.Teams)
+
+	fmt.Fprintf(&b, "\n## Violations\n\n| Rule | Applications |\n| --- | ---: |\n")
+	for _, rule := range sortedKeys(r.Total.Violations) {
+		fmt.Fprintf(&b, "| %s | %d |\n", rule, r.Total.Violations[rule])
+	}
+
+	return b.String()
+}
+
+func (p Profile) rules() (map[string]bool, error) {
+	known := map[string]bool{}
+	for _, rule := range argoapp.LintRules {
+		known[rule] = true
+	}
+
+	if len(p.Rules) == 0 {
+		return known, nil
+	}
+
+	rules := map[string]bool{}
+	for _, rule := range p.Rules {
+		if !known[rule] {
+			return nil, microerror.Maskf(invalidConfigError, "%T.Rules %#q is unknown, known rules are %v", p, rule, argoapp.LintRules)
+		}
+		rules[rule] = true
+	}
+
+	return rules, nil
+}
+
+func newSummary() Summary {
+	return Summary{
+		Violations: map[string]int{},
+	}
+}
+
+func summary(summaries map[string]Summary, key string) Summary {
+	s, ok := summaries[key]
+	if !ok {
+		s = newSummary()
+	}
+
+	return s
+}
+
+func (s Summary) add(result Result) Summary {
+	s.Applications++
+	if len(result.Violations) == 0 {
+		s.Compliant++
+	}
+	for _, rule := range result.Violations {
+		s.Violations[rule]++
+	}
+
+	return s
+}
+
+func writeSummaries(b *strings.Builder, title string, summaries map[string]Summary) {
+	fmt.Fprintf(b, "\n## %ss\n\n| %s | Applications | Compliant | %% |\n| --- | ---: | ---: | ---: |\n", title, title)
+	for _, key := range sortedKeys(summaries) {
+		s := summaries[key]
+		name := key
+		if name == "" {
+			name = "(none)"
+		}
+		fmt.Fprintf(b, "| %s | %d | %d | %s |\n", name, s.Applications, s.Compliant, percent(s))
+	}
+}
+
+func percent(s Summary) string {
+	if s.Applications == 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%.0f%%", float64(s.Compliant)*100/float64(s.Applications))
+}
+
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch m := m.(type) {
+	case map[string]Summary:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]int:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,148 @@
+// Package crossplane wraps the Applications generated by package argoapp into
+// Crossplane resources, so installations orchestrated by Crossplane can
+// consume them without an intermediate operator.
+package crossplane
+
+import (
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// CompositionResource is the Crossplane Composition resource.
+var CompositionResource = schema.GroupVersionResource{
+	Group:    "apiextensions.crossplane.io",
+	Version:  "v1",
+	Resource: "compositions",
+}
+
+// ObjectResource is the provider-kubernetes Object resource.
+var ObjectResource = schema.GroupVersionResource{
+	Group:    "kubernetes.crossplane.io",
+	Version:  "v1alpha1",
+	Resource: "objects",
+}
+
+type Config struct {
+	// ProviderConfigName is the provider-kubernetes ProviderConfig of the
+	// cluster Argo CD runs in.
+	ProviderConfigName string
+
+	// Name of the Composition. It is only required by NewComposition.
+	Name string
+	// CompositeAPIVersion and CompositeKind are the composite resource type
+	// the Composition is for, e.g. "platform.example.com/v1alpha1" and
+	// "XAppFleet". They are only required by NewComposition.
+	CompositeAPIVersion string
+	CompositeKind       string
+}
+
+// NewObject returns the provider-kubernetes Object managing the Application.
+// Objects are cluster scoped and named after the Application.
+func NewObject(app *unstructured.Unstructured, config Config) (*unstructured.Unstructured, error) {
+	if config.ProviderConfigName == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ProviderConfigName must not be empty", config)
+	}
+
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": ObjectResource.GroupVersion().String(),
+			"kind":       "Object",
+			"metadata": map[string]interface{}{
+				"name": app.GetName(),
+			},
+			"spec": map[string]interface{}{
+				"forProvider": map[string]interface{}{
+					"manifest": newManifest(app),
+				},
+				"providerConfigRef": map[string]interface{}{
+					"name": config.ProviderConfigName,
+				},
+			},
+		},
+	}
+
+	return obj, nil
+}
+
+// NewComposition returns the Composition composing the Objects of all the
+// Applications. The Composition resources are named after the Applications.
+func NewComposition(apps []*unstructured.Unstructured, config Config) (*unstructured.Unstructured, error) {
+	if errs := validation.IsDNS1123Subdomain(config.Name); len(errs) > 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Name %#q is invalid: %v", config, config.Name, errs)
+	}
+	if config.CompositeAPIVersion == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CompositeAPIVersion must not be empty", config)
+	}
+	if config.CompositeKind == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CompositeKind must not be empty", config)
+	}
+
+	names := map[string]bool{}
+	var resources []interface{}
+	for _, app := range apps {
+		if names[app.GetName()] {
+			return nil, microerror.Maskf(invalidConfigError, "Application name %#q is not unique", app.GetName())
+		}
+		names[app.GetName()] = true
+
+		obj, err := NewObject(app, config)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		resources = append(resources, map[string]interface{}{
+			"name": app.GetName(),
+			"base": obj.Object,
+		})
+	}
+
+	composition := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": CompositionResource.GroupVersion().String(),
+			"kind":       "Composition",
+			"metadata": map[string]interface{}{
+				"name": config.Name,
+			},
+			"spec": map[string]interface{}{
+				"compositeTypeRef": map[string]interface{}{
+					"apiVersion": config.CompositeAPIVersion,
+					"kind":       config.CompositeKind,
+				},
+				"resources": resources,
+			},
+		},
+	}
+
+	return composition, nil
+}
+
+// newManifest returns a copy of the Application without the status and the
+// metadata set by the API server, so it can be applied by Crossplane.
+func newManifest(app *unstructured.Unstructured) map[string]interface{} {
+	c := app.DeepCopy()
+
+	metadata := map[string]interface{}{
+		"name": c.GetName(),
+	}
+	if c.GetNamespace() != "" {
+		metadata["namespace"] = c.GetNamespace()
+	}
+	for _, k := range []string{"labels", "annotations", "finalizers"} {
+		if v, ok, _ := unstructured.NestedFieldNoCopy(c.Object, "metadata", k); ok {
+			metadata[k] = v
+		}
+	}
+
+	manifest := map[string]interface{}{
+		"apiVersion": c.GetAPIVersion(),
+		"kind":       c.GetKind(),
+		"metadata":   metadata,
+	}
+	if spec, ok := c.Object["spec"]; ok {
+		manifest["spec"] = spec
+	}
+
+	return manifest
+}
@@ -0,0 +1,234 @@
+// Package dashboards generates Grafana dashboards of the Applications
+// generated by package argoapp per installation and team, based on the
+// metrics exported by the Argo CD application controller.
+package dashboards
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/giantswarm/argoapp/pkg/argoapp"
+	"github.com/giantswarm/argoapp/pkg/tenancy"
+)
+
+const (
+	// ConfigMapLabel is the label of the ConfigMaps the Grafana dashboard
+	// sidecar loads the dashboards from.
+	ConfigMapLabel = "grafana_dashboard"
+
+	defaultInstallationLabel = "installation"
+	dashboardUIDPrefix       = "argoapp-"
+)
+
+// invalidMetricLabelChars are replaced with "_" when Argo CD exports the
+// Application labels as argocd_app_labels labels.
+var invalidMetricLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+type Config struct {
+	// Installation is the name of the installation the dashboard shows.
+	Installation string
+	// InstallationLabel is the Prometheus label holding the installation
+	// name. Defaults to "installation".
+	InstallationLabel string
+	// Team is optional. When set, the dashboard only shows the Applications
+	// of the team. It requires the Argo CD application controller to export
+	// the TeamLabel with --metrics-application-labels.
+	Team string
+	// TeamLabel is the Application label holding the owning team. Defaults
+	// to tenancy.TenantLabel.
+	TeamLabel string
+	// ManagedOnly shows only the Applications with the
+	// argoapp.ManagedByLabel. It requires the Argo CD application controller
+	// to export the label with --metrics-application-labels.
+	ManagedOnly bool
+	// Title defaults to "Argo CD Applications / <installation>" followed by
+	// " / <team>" for team dashboards.
+	Title string
+}
+
+// NewDashboard returns the Grafana dashboard JSON model. The dashboard UID is
+// derived from the installation and team so regenerating it updates the
+// existing dashboard.
+func NewDashboard(config Config) ([]byte, error) {
+	if config.Installation == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Installation must not be empty", config)
+	}
+	if config.InstallationLabel == "" {
+		config.InstallationLabel = defaultInstallationLabel
+	}
+	if config.TeamLabel == "" {
+		config.TeamLabel = tenancy.TenantLabel
+	}
+	if config.Title == "" {
+		config.Title = "Argo CD Applications / " + config.Installation
+		if config.Team != "" {
+			config.Title += " / " + config.Team
+		}
+	}
+
+	sum := sha256.Sum256([]byte(config.Installation + "/" + config.Team))
+
+	dashboard := map[string]interface{}{
+		"uid":           dashboardUIDPrefix + hex.EncodeToString(sum[:])[:12],
+		"title":         config.Title,
+		"tags":          []interface{}{"argoapp", "argocd"},
+		"schemaVersion": 36,
+		"editable":      false,
+		"refresh":       "1m",
+		"time": map[string]interface{}{
+			"from": "now-6h",
+			"to":   "now",
+		},
+		"templating": map[string]interface{}{
+			"list": []interface{}{
+				map[string]interface{}{
+					"name":  "datasource",
+					"label": "Data source",
+					"type":  "datasource",
+					"query": "prometheus",
+				},
+			},
+		},
+		"panels": config.panels(),
+	}
+
+	data, err := json.MarshalIndent(dashboard, "", "  ")
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return data, nil
+}
+
+// NewConfigMap returns the ConfigMap with the dashboard loaded by the Grafana
+// dashboard sidecar.
+func NewConfigMap(config Config, name, namespace string) (*unstructured.Unstructured, error) {
+	if name == "" {
+		return nil, microerror.Maskf(invalidConfigError, "name must not be empty")
+	}
+	if namespace == "" {
+		return nil, microerror.Maskf(invalidConfigError, "namespace must not be empty")
+	}
+
+	dashboard, err := NewDashboard(config)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+				"labels": map[string]interface{}{
+					ConfigMapLabel:         "1",
+					argoapp.ManagedByLabel: argoapp.ManagedByLabelValue,
+				},
+			},
+			"data": map[string]interface{}{
+				name + ".json": string(dashboard),
+			},
+		},
+	}
+
+	return obj, nil
+}
+
+func (c Config) panels() []interface{} {
+	info := func(matcher string) string {
+		return c.scope(c.selector("argocd_app_info", matcher))
+	}
+
+	panels := []interface{}{
+		newPanel("stat", "Applications", 0, 0, 6, 4, target(fmt.Sprintf("count(%s) or vector(0)", info("")), "")),
+		newPanel("stat", "Out of sync", 6, 0, 6, 4, target(fmt.Sprintf("count(%s) or vector(0)", info(`sync_status="OutOfSync"`)), "")),
+		newPanel("stat", "Degraded", 12, 0, 6, 4, target(fmt.Sprintf("count(%s) or vector(0)", info(`health_status="Degraded"`)), "")),
+		newPanel("stat", "Failed syncs (1h)", 18, 0, 6, 4, target(fmt.Sprintf("sum(%s) or vector(0)", c.scope(fmt.Sprintf("increase(%s[1h])", c.selector("argocd_app_sync_total", `phase=~"Error|Failed"`)))), "")),
+		newPanel("piechart", "Sync status", 0, 4, 12, 8, target(fmt.Sprintf("count by (sync_status) (%s)", info("")), "{{sync_status}}")),
+		newPanel("piechart", "Health status", 12, 4, 12, 8, target(fmt.Sprintf("count by (health_status) (%s)", info("")), "{{health_status}}")),
+		newPanel("timeseries", "Syncs", 0, 12, 24, 8, target(fmt.Sprintf("sum by (phase) (%s)", c.scope(fmt.Sprintf("increase(%s[5m])", c.selector("argocd_app_sync_total", "")))), "{{phase}}")),
+	}
+
+	unhealthy := newPanel("table", "Unhealthy or out of sync Applications", 0, 20, 24, 10, target(info(`health_status!="Healthy"`)+" or "+info(`sync_status!="Synced"`), ""))
+	t := unhealthy["targets"].([]interface{})[0].(map[string]interface{})
+	t["instant"] = true
+	t["format"] = "table"
+	panels = append(panels, unhealthy)
+
+	for i, p := range panels {
+		p.(map[string]interface{})["id"] = i + 1
+	}
+
+	return panels
+}
+
+// selector returns the metric selector scoped to the installation.
+func (c Config) selector(metric string, matcher string) string {
+	matchers := []string{fmt.Sprintf("%s=%q", c.InstallationLabel, c.Installation)}
+	if matcher != "" {
+		matchers = append(matchers, matcher)
+	}
+
+	return fmt.Sprintf("%s{%s}", metric, strings.Join(matchers, ","))
+}
+
+// scope scopes the expression to the Applications of the team and the
+// managed Applications with ManagedOnly.
+func (c Config) scope(expr string) string {
+	var matchers []string
+	if c.ManagedOnly {
+		matchers = append(matchers, fmt.Sprintf("%s=%q", metricLabel(argoapp.ManagedByLabel), argoapp.ManagedByLabelValue))
+	}
+	if c.Team != "" {
+		matchers = append(matchers, fmt.Sprintf("%s=%q", metricLabel(c.TeamLabel), c.Team))
+	}
+	if len(matchers) == 0 {
+		return expr
+	}
+
+	return fmt.Sprintf("(%s) * on(name, namespace) group_left() %s", expr, c.selector("argocd_app_labels", strings.Join(matchers, ",")))
+}
+
+func newPanel(panelType, title string, x, y, w, h int, targets ...interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type":  panelType,
+		"title": title,
+		"datasource": map[string]interface{}{
+			"type": "prometheus",
+			"uid":  "${datasource}",
+		},
+		"gridPos": map[string]interface{}{
+			"x": x,
+			"y": y,
+			"w": w,
+			"h": h,
+		},
+		"targets": targets,
+	}
+}
+
+func target(expr, legendFormat string) interface{} {
+	t := map[string]interface{}{
+		"refId": "A",
+		"expr":  expr,
+	}
+	if legendFormat != "" {
+		t["legendFormat"] = legendFormat
+	}
+
+	return t
+}
+
+// metricLabel returns the argocd_app_labels label of the Application label.
+func metricLabel(key string) string {
+	return "label_" + invalidMetricLabelChars.ReplaceAllString(key, "_")
+}
@@ -0,0 +1,21 @@
+package ir
+
+import "github.com/giantswarm/microerror"
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var unsupportedFieldError = &microerror.Error{
+	Kind: "unsupportedFieldError",
+}
+
+// IsUnsupportedField asserts unsupportedFieldError.
+func IsUnsupportedField(err error) bool {
+	return microerror.Cause(err) == unsupportedFieldError
+}
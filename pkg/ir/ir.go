@@ -0,0 +1,194 @@
+// Package ir is a stable, JSON-serializable intermediate representation of
+// the Applications generated by package argoapp. Unlike the unstructured
+// objects it is typed and described by a JSON Schema, so infrastructure as
+// code tools in other languages, e.g. Pulumi or cdk8s, can consume the
+// generator output through cmd/argoapp-ir.
+//
+// The JSON field names follow the Argo CD Application CR. Fields are only
+// ever added to a Version, never renamed or removed.
+package ir
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/giantswarm/argoapp/pkg/argoapp"
+)
+
+// Version is the version of the representation set in Document.Version.
+const Version = "argoapp.giantswarm.io/ir/v1"
+
+const (
+	applicationAPIVersion = "argoproj.io/v1alpha1"
+	applicationKind       = "Application"
+)
+
+// Document is the top level object of the representation.
+type Document struct {
+	Version      string        `json:"version"`
+	Applications []Application `json:"applications"`
+}
+
+type Application struct {
+	Metadata Metadata `json:"metadata"`
+	Spec     Spec     `json:"spec"`
+}
+
+type Metadata struct {
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Finalizers  []string          `json:"finalizers,omitempty"`
+}
+
+type Spec struct {
+	Project              string      `json:"project"`
+	Source               Source      `json:"source"`
+	Destination          Destination `json:"destination"`
+	SyncPolicy           *SyncPolicy `json:"syncPolicy,omitempty"`
+	RevisionHistoryLimit *int64      `json:"revisionHistoryLimit,omitempty"`
+	Info                 []Info      `json:"info,omitempty"`
+}
+
+type Source struct {
+	RepoURL        string  `json:"repoURL"`
+	Path           string  `json:"path,omitempty"`
+	TargetRevision string  `json:"targetRevision"`
+	Plugin         *Plugin `json:"plugin,omitempty"`
+}
+
+type Plugin struct {
+	// Name is empty with argoapp.PluginModeSidecar.
+	Name string `json:"name,omitempty"`
+	Env  []Env  `json:"env,omitempty"`
+}
+
+type Env struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type Destination struct {
+	Server    string `json:"server,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+type SyncPolicy struct {
+	Automated                *Automated                `json:"automated,omitempty"`
+	Retry                    *Retry                    `json:"retry,omitempty"`
+	SyncOptions              []string                  `json:"syncOptions,omitempty"`
+	ManagedNamespaceMetadata *ManagedNamespaceMetadata `json:"managedNamespaceMetadata,omitempty"`
+}
+
+type Automated struct {
+	Prune      bool `json:"prune"`
+	SelfHeal   bool `json:"selfHeal"`
+	AllowEmpty bool `json:"allowEmpty"`
+}
+
+type Retry struct {
+	Limit   int64    `json:"limit"`
+	Backoff *Backoff `json:"backoff,omitempty"`
+}
+
+type Backoff struct {
+	Duration    string `json:"duration,omitempty"`
+	Factor      *int64 `json:"factor,omitempty"`
+	MaxDuration string `json:"maxDuration,omitempty"`
+}
+
+type ManagedNamespaceMetadata struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type Info struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// New generates the Applications of the configs like
+// argoapp.NewApplicationCollection and returns their Document.
+func New(configs []argoapp.ApplicationConfig) (*Document, error) {
+	apps, err := argoapp.NewApplicationCollection(configs)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	d := &Document{
+		Version:      Version,
+		Applications: []Application{},
+	}
+	for _, app := range apps {
+		a, err := FromUnstructured(app)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		d.Applications = append(d.Applications, a)
+	}
+
+	return d, nil
+}
+
+// FromUnstructured returns the representation of the Application. It returns
+// an error matched by IsUnsupportedField when the Application has fields
+// which are not part of the representation, e.g. status, so no field is
+// silently dropped.
+func FromUnstructured(app *unstructured.Unstructured) (Application, error) {
+	if app.GetAPIVersion() != applicationAPIVersion || app.GetKind() != applicationKind {
+		return Application{}, microerror.Maskf(invalidConfigError, "object %#q is %s %s, not %s %s", app.GetName(), app.GetAPIVersion(), app.GetKind(), applicationAPIVersion, applicationKind)
+	}
+
+	obj := map[string]interface{}{}
+	for k, v := range app.Object {
+		if k != "apiVersion" && k != "kind" {
+			obj[k] = v
+		}
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return Application{}, microerror.Mask(err)
+	}
+
+	var a Application
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err = dec.Decode(&a)
+	if err != nil {
+		return Application{}, microerror.Maskf(unsupportedFieldError, "Application %#q: %s", app.GetName(), err)
+	}
+
+	return a, nil
+}
+
+// ToUnstructured returns the Application CR of the representation.
+func (a Application) ToUnstructured() (*unstructured.Unstructured, error) {
+	manifest := struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+		Application
+	}{
+		APIVersion:  applicationAPIVersion,
+		Kind:        applicationKind,
+		Application: a,
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	// UnmarshalJSON decodes the numbers as int64 like the generator sets
+	// them.
+	obj := &unstructured.Unstructured{}
+	err = obj.UnmarshalJSON(data)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return obj, nil
+}
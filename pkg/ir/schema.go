@@ -0,0 +1,76 @@
+package ir
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// SchemaID is the $id of the JSON Schema returned by Schema.
+const SchemaID = "https://github.com/giantswarm/argoapp/ir/v1/document.schema.json"
+
+// Schema returns the JSON Schema (draft-07) of Document. It is derived from
+// the Go types so the two can not drift apart. Code generators like
+// quicktype or datamodel-codegen generate the types of other languages from
+// it.
+func Schema() []byte {
+	s := schemaOf(reflect.TypeOf(Document{}))
+	s["$schema"] = "http://json-schema.org/draft-07/schema#"
+	s["$id"] = SchemaID
+	s["title"] = "Document"
+	s["properties"].(map[string]interface{})["version"] = map[string]interface{}{
+		"type":  "string",
+		"const": Version,
+	}
+
+	// json.Marshal sorts the map keys.
+	data, _ := json.MarshalIndent(s, "", "  ")
+
+	return append(data, '\n')
+}
+
+func schemaOf(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaOf(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Slice:
+		return map[string]interface{}{
+			"type":  "array",
+			"items": schemaOf(t.Elem()),
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": schemaOf(t.Elem()),
+		}
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		var required []interface{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			split := strings.Split(f.Tag.Get("json"), ",")
+			properties[split[0]] = schemaOf(f.Type)
+			if len(split) == 1 {
+				required = append(required, split[0])
+			}
+		}
+
+		s := map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+		if len(required) > 0 {
+			s["required"] = required
+		}
+		return s
+	default:
+		panic("unsupported type " + t.String())
+	}
+}
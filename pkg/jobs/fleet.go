@@ -0,0 +1,158 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/giantswarm/argoapp/pkg/argoapp"
+	"github.com/giantswarm/argoapp/pkg/argoappclient"
+)
+
+// BumpConfigRef returns a Func setting the config repository revision of all
+// the Applications matching the selector to configRef. Applications frozen
+// with the argoapp.FrozenAnnotation are skipped. Applications pinned with
+// argoapp.PinConfigRef keep their pinned revision, configRef is recorded in
+// the argoapp.PinnedFromAnnotation to be restored when they are unpinned,
+// like with argoapp.UpdateApplicationConfig.
+func BumpConfigRef(applications argoappclient.ResourceInterface, selector labels.Selector, configRef string) Func {
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"source": map[string]interface{}{
+				"targetRevision": configRef,
+			},
+		},
+	}
+	pinnedPatch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				argoapp.PinnedFromAnnotation: configRef,
+			},
+		},
+	}
+
+	return forEach(applications, selector, skipFrozen, func(ctx context.Context, app unstructured.Unstructured) error {
+		if argoapp.IsPinned(&app) {
+			return microerror.Mask(mergePatch(ctx, applications, app.GetName(), pinnedPatch))
+		}
+
+		return microerror.Mask(mergePatch(ctx, applications, app.GetName(), patch))
+	})
+}
+
+// Resync returns a Func triggering a sync of all the Applications matching
+// the selector. Frozen Applications and Applications with an operation in
+// progress, e.g. a sync or a rollback, are skipped.
+func Resync(applications argoappclient.ResourceInterface, selector labels.Selector) Func {
+	patch := map[string]interface{}{
+		"operation": map[string]interface{}{
+			"initiatedBy": map[string]interface{}{
+				"username": "argoapp",
+			},
+			"sync": map[string]interface{}{},
+		},
+	}
+
+	skip := func(app *unstructured.Unstructured) string {
+		if reason := skipFrozen(app); reason != "" {
+			return reason
+		}
+		if _, ok := app.Object["operation"]; ok || argoappclient.IsOperationInProgress(app) {
+			return "operation in progress"
+		}
+		return ""
+	}
+
+	return forEach(applications, selector, skip, func(ctx context.Context, app unstructured.Unstructured) error {
+		return microerror.Mask(mergePatch(ctx, applications, app.GetName(), patch))
+	})
+}
+
+// Verify returns a Func running the post-sync checks for all the
+// Applications matching the selector.
+func Verify(applications argoappclient.ResourceInterface, selector labels.Selector, checks *argoappclient.PostSyncChecks) Func {
+	return forEach(applications, selector, nil, func(ctx context.Context, app unstructured.Unstructured) error {
+		return microerror.Mask(checks.Run(ctx, &app))
+	})
+}
+
+// forEach returns a Func calling fn for every Application matching the
+// selector. Failures do not stop the job, they are all reported in the
+// returned error. The Applications for which the optional skip returns a
+// reason are reported as skipped instead.
+func forEach(applications argoappclient.ResourceInterface, selector labels.Selector, skip func(app *unstructured.Unstructured) string, fn func(ctx context.Context, app unstructured.Unstructured) error) Func {
+	return func(ctx context.Context, report func(Progress)) error {
+		list, err := applications.List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		report(Progress{Total: len(list.Items)})
+
+		var failures []string
+		var skipped []Skipped
+		for i, app := range list.Items {
+			if ctx.Err() != nil {
+				return microerror.Mask(ctx.Err())
+			}
+
+			if reason := skipReason(skip, &app); reason != "" {
+				skipped = append(skipped, Skipped{
+					Application: app.GetName(),
+					Reason:      reason,
+				})
+			} else {
+				err = fn(ctx, app)
+				if err != nil {
+					failures = append(failures, fmt.Sprintf("%s: %s", app.GetName(), err))
+				}
+			}
+
+			report(Progress{Done: i + 1, Total: len(list.Items), Skipped: skipped})
+		}
+
+		if len(failures) > 0 {
+			return microerror.Maskf(executionFailedError, "%d of %d Applications failed: %s", len(failures), len(list.Items), strings.Join(failures, "; "))
+		}
+
+		return nil
+	}
+}
+
+func skipReason(skip func(app *unstructured.Unstructured) string, app *unstructured.Unstructured) string {
+	if skip == nil {
+		return ""
+	}
+
+	return skip(app)
+}
+
+// skipFrozen skips the Applications with the argoapp.FrozenAnnotation.
+func skipFrozen(app *unstructured.Unstructured) string {
+	if !argoapp.IsFrozen(app) {
+		return ""
+	}
+
+	return "frozen: " + app.GetAnnotations()[argoapp.FrozenAnnotation]
+}
+
+func mergePatch(ctx context.Context, applications argoappclient.ResourceInterface, name string, patch map[string]interface{}) error {
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	_, err = applications.Patch(ctx, name, types.MergePatchType, data, metav1.PatchOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
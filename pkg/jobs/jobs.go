@@ -0,0 +1,379 @@
+// Package jobs runs long-running fleet operations, e.g. bumping the config
+// ref of all Applications, in the background with progress reporting and
+// cancellation.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+)
+
+type State string
+
+const (
+	StatePending   State = "Pending"
+	StateRunning   State = "Running"
+	StateSucceeded State = "Succeeded"
+	StateFailed    State = "Failed"
+	StateCancelled State = "Cancelled"
+)
+
+// Job is the persisted state of a submitted job.
+type Job struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	State      State      `json:"state"`
+	Progress   Progress   `json:"progress"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// Progress is the progress of a job, e.g. the number of processed
+// Applications out of all the Applications.
+type Progress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+	// Skipped are the processed Applications the job left untouched.
+	Skipped []Skipped `json:"skipped,omitempty"`
+}
+
+// Skipped is an Application left untouched by a job and the reason why, e.g.
+// the value of the argoapp.FrozenAnnotation.
+type Skipped struct {
+	Application string `json:"application"`
+	Reason      string `json:"reason"`
+}
+
+// Func is the job body. It reports its progress with report and must return
+// when ctx is cancelled.
+type Func func(ctx context.Context, report func(Progress)) error
+
+// Store persists jobs, e.g. in a ConfigMap or a database, so their outcome
+// survives restarts of the process. Jobs not finished when the process
+// stopped are marked failed on the next Run as job functions can not be
+// persisted.
+type Store interface {
+	Save(ctx context.Context, job Job) error
+	List(ctx context.Context) ([]Job, error)
+}
+
+type RunnerConfig struct {
+	// Store defaults to an in-memory store.
+	Store Store
+	// Workers is the number of jobs run concurrently. Defaults to 1.
+	Workers int
+}
+
+// Runner is an in-memory queue of jobs.
+//
+// Runner is safe for concurrent use.
+type Runner struct {
+	store   Store
+	workers int
+
+	mutex   sync.Mutex
+	jobs    map[string]*Job
+	funcs   map[string]Func
+	cancels map[string]context.CancelFunc
+	queue   []string
+	// wake is signalled when a job is queued.
+	wake chan struct{}
+}
+
+func NewRunner(config RunnerConfig) (*Runner, error) {
+	if config.Store == nil {
+		config.Store = NewMemoryStore()
+	}
+	if config.Workers < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Workers must not be negative", config)
+	}
+	if config.Workers == 0 {
+		config.Workers = 1
+	}
+
+	r := &Runner{
+		store:   config.Store,
+		workers: config.Workers,
+
+		jobs:    map[string]*Job{},
+		funcs:   map[string]Func{},
+		cancels: map[string]context.CancelFunc{},
+		wake:    make(chan struct{}, 1),
+	}
+
+	return r, nil
+}
+
+// Run loads the persisted jobs and runs the submitted jobs until ctx is
+// cancelled. Running jobs are cancelled when it returns.
+func (r *Runner) Run(ctx context.Context) error {
+	persisted, err := r.store.List(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, job := range persisted {
+		job := job
+		if job.State == StatePending || job.State == StateRunning {
+			now := time.Now()
+			job.State = StateFailed
+			job.Error = "interrupted by restart"
+			job.FinishedAt = &now
+
+			err = r.store.Save(ctx, job)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		r.mutex.Lock()
+		if _, ok := r.jobs[job.ID]; !ok {
+			r.jobs[job.ID] = &job
+		}
+		r.mutex.Unlock()
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < r.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				id, ok := r.next()
+				if ok {
+					r.run(ctx, id)
+					continue
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-r.wake:
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// Submit queues the job and returns its ID.
+func (r *Runner) Submit(ctx context.Context, name string, fn Func) (string, error) {
+	id, err := newID()
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	job := &Job{
+		ID:        id,
+		Name:      name,
+		State:     StatePending,
+		CreatedAt: time.Now(),
+	}
+
+	err = r.store.Save(ctx, *job)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	r.mutex.Lock()
+	r.jobs[id] = job
+	r.funcs[id] = fn
+	r.queue = append(r.queue, id)
+	r.mutex.Unlock()
+
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+
+	return id, nil
+}
+
+// Get returns the job with the given ID.
+func (r *Runner) Get(id string) (Job, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	job, ok := r.jobs[id]
+	if !ok {
+		return Job{}, microerror.Maskf(notFoundError, "job %#q", id)
+	}
+
+	return *job, nil
+}
+
+// List returns all the jobs ordered by creation time.
+func (r *Runner) List() []Job {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var jobs []Job
+	for _, job := range r.jobs {
+		jobs = append(jobs, *job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.Before(jobs[j].CreatedAt) })
+
+	return jobs
+}
+
+// Cancel cancels the job with the given ID. Cancelling a finished job is a
+// no-op.
+func (r *Runner) Cancel(ctx context.Context, id string) error {
+	r.mutex.Lock()
+	job, ok := r.jobs[id]
+	if !ok {
+		r.mutex.Unlock()
+		return microerror.Maskf(notFoundError, "job %#q", id)
+	}
+
+	var save *Job
+	switch job.State {
+	case StatePending:
+		now := time.Now()
+		job.State = StateCancelled
+		job.FinishedAt = &now
+		delete(r.funcs, id)
+		cancelled := *job
+		save = &cancelled
+	case StateRunning:
+		r.cancels[id]()
+	}
+	r.mutex.Unlock()
+
+	if save != nil {
+		return microerror.Mask(r.store.Save(ctx, *save))
+	}
+
+	return nil
+}
+
+// next pops the next queued job ID.
+func (r *Runner) next() (string, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if len(r.queue) == 0 {
+		return "", false
+	}
+
+	id := r.queue[0]
+	r.queue = r.queue[1:]
+
+	// Wake another worker when more jobs are queued.
+	if len(r.queue) > 0 {
+		select {
+		case r.wake <- struct{}{}:
+		default:
+		}
+	}
+
+	return id, true
+}
+
+func (r *Runner) run(ctx context.Context, id string) {
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	r.mutex.Lock()
+	fn, ok := r.funcs[id]
+	if !ok {
+		// Cancelled while pending.
+		r.mutex.Unlock()
+		return
+	}
+	delete(r.funcs, id)
+	job := r.jobs[id]
+	now := time.Now()
+	job.State = StateRunning
+	job.StartedAt = &now
+	r.cancels[id] = cancel
+	started := *job
+	r.mutex.Unlock()
+
+	_ = r.store.Save(ctx, started)
+
+	report := func(p Progress) {
+		r.mutex.Lock()
+		job.Progress = p
+		saved := *job
+		r.mutex.Unlock()
+
+		_ = r.store.Save(ctx, saved)
+	}
+
+	err := fn(jobCtx, report)
+
+	r.mutex.Lock()
+	finished := time.Now()
+	job.FinishedAt = &finished
+	switch {
+	case err == nil:
+		job.State = StateSucceeded
+	case jobCtx.Err() != nil:
+		job.State = StateCancelled
+		job.Error = err.Error()
+	default:
+		job.State = StateFailed
+		job.Error = err.Error()
+	}
+	delete(r.cancels, id)
+	saved := *job
+	r.mutex.Unlock()
+
+	// The job context may be cancelled by now, the final state is saved
+	// regardless.
+	_ = r.store.Save(context.Background(), saved)
+}
+
+func newID() (string, error) {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// MemoryStore is a Store keeping the jobs in memory.
+type MemoryStore struct {
+	mutex sync.Mutex
+	jobs  map[string]Job
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		jobs: map[string]Job{},
+	}
+}
+
+func (s *MemoryStore) Save(ctx context.Context, job Job) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.jobs[job.ID] = job
+
+	return nil
+}
+
+func (s *MemoryStore) List(ctx context.Context) ([]Job, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var jobs []Job
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
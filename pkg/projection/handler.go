@@ -0,0 +1,198 @@
+package projection
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/giantswarm/argoapp/pkg/argoappclient"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+type HandlerConfig struct {
+	Applications argoappclient.ResourceInterface
+
+	// Selector limits the served Applications. Defaults to all
+	// Applications.
+	Selector labels.Selector
+}
+
+// Handler serves the Application projections over HTTP:
+//
+//	GET /applications         lists the projections as JSON.
+//	GET /applications/events  streams the changes as server-sent events.
+//
+// Both endpoints can be filtered with the app, version, catalog, cluster,
+// namespace, sync and health query parameters matching the projection
+// fields. The list is paginated with the limit and offset query parameters.
+type Handler struct {
+	applications argoappclient.ResourceInterface
+	selector     labels.Selector
+	mux          *http.ServeMux
+}
+
+// ListResponse is the response of GET /applications.
+type ListResponse struct {
+	Items []Application `json:"items"`
+	Total int           `json:"total"`
+	// NextOffset is the offset of the next page. It is omitted on the last
+	// page.
+	NextOffset *int `json:"nextOffset,omitempty"`
+}
+
+func NewHandler(config HandlerConfig) (*Handler, error) {
+	if config.Applications == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Applications must not be empty", config)
+	}
+	if config.Selector == nil {
+		config.Selector = labels.Everything()
+	}
+
+	h := &Handler{
+		applications: config.Applications,
+		selector:     config.Selector,
+		mux:          http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("/applications", h.list)
+	h.mux.HandleFunc("/applications/events", h.events)
+
+	return h, nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit, err := intParam(r, "limit", defaultLimit)
+	if err != nil || limit <= 0 || limit > maxLimit {
+		http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxLimit), http.StatusBadRequest)
+		return
+	}
+	offset, err := intParam(r, "offset", 0)
+	if err != nil || offset < 0 {
+		http.Error(w, "offset must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	projections, err := List(r.Context(), h.applications, h.selector)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var filtered []Application
+	for _, a := range projections {
+		if matches(r, a) {
+			filtered = append(filtered, a)
+		}
+	}
+	sort.Slice(filtered, func(i, j int) bool { return filtered[i].Name < filtered[j].Name })
+
+	res := ListResponse{
+		Items: []Application{},
+		Total: len(filtered),
+	}
+	if offset < len(filtered) {
+		end := offset + limit
+		if end < len(filtered) {
+			res.NextOffset = &end
+		} else {
+			end = len(filtered)
+		}
+		res.Items = filtered[offset:end]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+func (h *Handler) events(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	events, err := Watch(r.Context(), h.applications, h.selector)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for e := range events {
+		if !matches(r, e.Application) {
+			continue
+		}
+
+		data, err := json.Marshal(e)
+		if err != nil {
+			continue
+		}
+
+		_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+func matches(r *http.Request, a Application) bool {
+	q := r.URL.Query()
+
+	for param, value := range map[string]string{
+		"app":       a.App,
+		"version":   a.Version,
+		"catalog":   a.Catalog,
+		"cluster":   a.Cluster,
+		"namespace": a.Namespace,
+		"sync":      a.Sync,
+		"health":    a.Health,
+	} {
+		if v := q.Get(param); v != "" && v != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+func intParam(r *http.Request, name string, def int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+
+	return i, nil
+}
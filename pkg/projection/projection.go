@@ -0,0 +1,142 @@
+// Package projection provides a compact read model of Argo CD Applications
+// generated with package argoapp, e.g. as the data layer for UI backends.
+package projection
+
+import (
+	"context"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/giantswarm/argoapp/pkg/argoapp"
+	"github.com/giantswarm/argoapp/pkg/argoappclient"
+)
+
+// Application is the read model of an Argo CD Application.
+type Application struct {
+	Name string `json:"name"`
+
+	App     string `json:"app"`
+	Version string `json:"version"`
+	Catalog string `json:"catalog"`
+	// DisableForceUpgrade is argoapp.ApplicationConfig.DisableForceUpgrade.
+	DisableForceUpgrade bool `json:"disableForceUpgrade,omitempty"`
+
+	// Cluster is the destination cluster name or server URL.
+	Cluster   string `json:"cluster"`
+	Namespace string `json:"namespace"`
+
+	// Sync is the sync status, e.g. "Synced" or "OutOfSync".
+	Sync string `json:"sync"`
+	// Health is the health status, e.g. "Healthy" or "Degraded".
+	Health string `json:"health"`
+	// LastDeploy is the time of the last successful sync.
+	LastDeploy *time.Time `json:"lastDeploy,omitempty"`
+}
+
+// Event is a change of an Application observed with Watch.
+type Event struct {
+	// Type is watch.Added, watch.Modified or watch.Deleted.
+	Type        watch.EventType `json:"type"`
+	Application Application     `json:"application"`
+}
+
+// FromUnstructured projects the Application CR. The app fields are read with
+// argoapp.ParseApplicationConfig and left empty for Applications it can not
+// parse, e.g. not generated with package argoapp.
+func FromUnstructured(obj *unstructured.Unstructured) Application {
+	a := Application{
+		Name: obj.GetName(),
+	}
+
+	config, err := argoapp.ParseApplicationConfig(obj)
+	if err == nil {
+		a.App = config.AppName
+		a.Version = config.AppVersion
+		a.Catalog = config.AppCatalog
+		a.DisableForceUpgrade = config.DisableForceUpgrade
+	}
+
+	a.Cluster, _, _ = unstructured.NestedString(obj.Object, "spec", "destination", "name")
+	if a.Cluster == "" {
+		a.Cluster, _, _ = unstructured.NestedString(obj.Object, "spec", "destination", "server")
+	}
+	a.Namespace, _, _ = unstructured.NestedString(obj.Object, "spec", "destination", "namespace")
+
+	a.Sync, _, _ = unstructured.NestedString(obj.Object, "status", "sync", "status")
+	a.Health, _, _ = unstructured.NestedString(obj.Object, "status", "health", "status")
+
+	history, _, _ := unstructured.NestedSlice(obj.Object, "status", "history")
+	if len(history) > 0 {
+		last, _ := history[len(history)-1].(map[string]interface{})
+		deployedAt, _ := last["deployedAt"].(string)
+		t, err := time.Parse(time.RFC3339, deployedAt)
+		if err == nil {
+			a.LastDeploy = &t
+		}
+	}
+
+	return a
+}
+
+// List returns the projections of the Applications matching the selector.
+func List(ctx context.Context, applications argoappclient.ResourceInterface, selector labels.Selector) ([]Application, error) {
+	list, err := applications.List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var projections []Application
+	for _, item := range list.Items {
+		projections = append(projections, FromUnstructured(&item))
+	}
+
+	return projections, nil
+}
+
+// Watch streams the changes of the Applications matching the selector. The
+// returned channel is closed when ctx is done or the underlying watch ends.
+func Watch(ctx context.Context, applications argoappclient.ResourceInterface, selector labels.Selector) (<-chan Event, error) {
+	w, err := applications.Watch(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		defer w.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-w.ResultChan():
+				if !ok {
+					return
+				}
+				obj, ok := e.Object.(*unstructured.Unstructured)
+				if !ok {
+					continue
+				}
+				switch e.Type {
+				case watch.Added, watch.Modified, watch.Deleted:
+				default:
+					continue
+				}
+
+				select {
+				case events <- Event{Type: e.Type, Application: FromUnstructured(obj)}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
@@ -0,0 +1,211 @@
+// Package scenario runs declarative Application generation test cases, so
+// test cases can be contributed without writing Go. Each YAML file in a
+// directory holds a list of scenarios:
+//
+//	# testdata/scenarios/hello-world.yaml
+//	- name: hello-world
+//	  config:
+//	    name: hello-world
+//	    appName: hello-world
+//	    appVersion: 1.0.0
+//	    appCatalog: giantswarm
+//	    appDestinationNamespace: hello
+//	    configRef: v1
+//	  expected:
+//	    spec:
+//	      destination:
+//	        namespace: hello
+//	- name: missing-config-ref
+//	  config:
+//	    name: hello-world
+//	  expectedErrors:
+//	  - ConfigRef must not be empty
+//
+// The config field names are matched case insensitively. The scenarios are
+// run as subtests with:
+//
+//	func TestScenarios(t *testing.T) {
+//		scenario.Run(t, "testdata/scenarios")
+//	}
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/giantswarm/microerror"
+	"sigs.k8s.io/yaml"
+
+	"github.com/giantswarm/argoapp/pkg/argoapp"
+)
+
+// Scenario is a generation test case.
+type Scenario struct {
+	Name   string                    `json:"name"`
+	Config argoapp.ApplicationConfig `json:"config"`
+	// Expected is the expected generated Application. Only the fields it
+	// sets are compared, lists are compared as a whole.
+	Expected map[string]interface{} `json:"expected,omitempty"`
+	// ExpectedErrors are the substrings the validation error must contain.
+	// When set, the generation must fail.
+	ExpectedErrors []string `json:"expectedErrors,omitempty"`
+}
+
+// Load reads the scenarios of all the YAML files in the directory. The
+// scenario names must be unique.
+func Load(dir string) ([]Scenario, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	sort.Strings(files)
+
+	var scenarios []Scenario
+	names := map[string]string{}
+	for _, f := range files {
+		data, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		var s []Scenario
+		err = yaml.Unmarshal(data, &s)
+		if err != nil {
+			return nil, microerror.Maskf(invalidConfigError, "%s: %s", f, err)
+		}
+
+		for i, sc := range s {
+			if sc.Name == "" {
+				return nil, microerror.Maskf(invalidConfigError, "%s: scenario %d has no name", f, i)
+			}
+			if other, ok := names[sc.Name]; ok {
+				return nil, microerror.Maskf(invalidConfigError, "%s: scenario %#q is already defined in %s", f, sc.Name, other)
+			}
+			if sc.Expected == nil && len(sc.ExpectedErrors) == 0 {
+				return nil, microerror.Maskf(invalidConfigError, "%s: scenario %#q has neither expected nor expectedErrors", f, sc.Name)
+			}
+			names[sc.Name] = f
+		}
+		scenarios = append(scenarios, s...)
+	}
+
+	return scenarios, nil
+}
+
+// Run loads the scenarios of the directory and runs each as a subtest.
+func Run(t *testing.T, dir string) {
+	t.Helper()
+
+	scenarios, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scenarios) == 0 {
+		t.Fatalf("no scenarios in %s", dir)
+	}
+
+	for _, s := range scenarios {
+		s := s
+		t.Run(s.Name, func(t *testing.T) {
+			err := s.Check()
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// Check generates the Application of the scenario and returns an error
+// matched by IsMismatch when it does not meet the expectations.
+func (s Scenario) Check() error {
+	obj, err := argoapp.NewApplication(s.Config)
+	if len(s.ExpectedErrors) > 0 {
+		if err == nil {
+			return microerror.Maskf(mismatchError, "expected errors %q, got none", s.ExpectedErrors)
+		}
+		var missing []string
+		for _, e := range s.ExpectedErrors {
+			if !strings.Contains(err.Error(), e) {
+				missing = append(missing, e)
+			}
+		}
+		if len(missing) > 0 {
+			return microerror.Maskf(mismatchError, "expected errors %q, got %q", missing, err.Error())
+		}
+		return nil
+	} else if err != nil {
+		return microerror.Maskf(mismatchError, "expected no error, got %q", err.Error())
+	}
+
+	// Normalize the generated Application to the types decoded from YAML.
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	var generated map[string]interface{}
+	err = json.Unmarshal(data, &generated)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	problems := compare("", s.Expected, generated)
+	if len(problems) > 0 {
+		return microerror.Maskf(mismatchError, "%s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// compare returns the paths where actual does not match expected.
+func compare(path string, expected, actual interface{}) []string {
+	e, ok := expected.(map[string]interface{})
+	if !ok {
+		if !reflect.DeepEqual(expected, actual) {
+			return []string{fmt.Sprintf("%s: expected %s, got %s", path, toJSON(expected), toJSON(actual))}
+		}
+		return nil
+	}
+
+	a, ok := actual.(map[string]interface{})
+	if !ok {
+		return []string{fmt.Sprintf("%s: expected an object, got %s", path, toJSON(actual))}
+	}
+
+	var keys []string
+	for k := range e {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var problems []string
+	for _, k := range keys {
+		p := k
+		if path != "" {
+			p = path + "." + k
+		}
+
+		v, ok := a[k]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: missing", p))
+			continue
+		}
+		problems = append(problems, compare(p, e[k], v)...)
+	}
+
+	return problems
+}
+
+func toJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+
+	return string(data)
+}
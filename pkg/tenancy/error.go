@@ -0,0 +1,30 @@
+package tenancy
+
+import "github.com/giantswarm/microerror"
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var policyViolationError = &microerror.Error{
+	Kind: "policyViolationError",
+}
+
+// IsPolicyViolation asserts policyViolationError.
+func IsPolicyViolation(err error) bool {
+	return microerror.Cause(err) == policyViolationError
+}
+
+var quotaExceededError = &microerror.Error{
+	Kind: "quotaExceededError",
+}
+
+// IsQuotaExceeded asserts quotaExceededError.
+func IsQuotaExceeded(err error) bool {
+	return microerror.Cause(err) == quotaExceededError
+}
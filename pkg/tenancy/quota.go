@@ -0,0 +1,107 @@
+package tenancy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/giantswarm/argoapp/pkg/argoapp"
+	"github.com/giantswarm/argoapp/pkg/argoappclient"
+)
+
+// TenantLabel is the label of Applications holding the name of the tenant
+// they are accounted to.
+const TenantLabel = "argoapp.giantswarm.io/tenant"
+
+// Usage is the resource usage of a tenant.
+type Usage struct {
+	Applications int `json:"applications"`
+	// Namespaces is the number of Applications per destination namespace.
+	Namespaces map[string]int `json:"namespaces"`
+	// Clusters is the number of Applications per destination cluster name
+	// or server.
+	Clusters map[string]int `json:"clusters"`
+}
+
+// ComputeUsage returns the usage of the tenant from the Applications labeled
+// with TenantLabel.
+func ComputeUsage(tenant string, apps []unstructured.Unstructured) Usage {
+	return computeUsage(tenant, apps, "")
+}
+
+// GetUsage returns the usage of every tenant of the policy.
+func GetUsage(ctx context.Context, applications argoappclient.ResourceInterface, p Policy) (map[string]Usage, error) {
+	list, err := applications.List(ctx, metav1.ListOptions{LabelSelector: TenantLabel})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	usage := map[string]Usage{}
+	for _, t := range p.Tenants {
+		usage[t.Name] = ComputeUsage(t.Name, list.Items)
+	}
+
+	return usage, nil
+}
+
+// CheckQuota returns an error matched by IsQuotaExceeded listing all the
+// exceeded quotas when the tenant creates or updates the Application
+// described by config in addition to apps, the existing Applications.
+func (p Policy) CheckQuota(tenant string, config argoapp.ApplicationConfig, apps []unstructured.Unstructured) error {
+	t, ok := p.tenant(tenant)
+	if !ok {
+		return microerror.Maskf(policyViolationError, "tenant %#q is not allowed to deploy any Application", tenant)
+	}
+
+	// The Application being updated is replaced by config.
+	usage := computeUsage(tenant, apps, config.Name)
+	cluster := configCluster(config)
+
+	var exceeded []string
+	if t.MaxApplications > 0 && usage.Applications+1 > t.MaxApplications {
+		exceeded = append(exceeded, fmt.Sprintf("maximum of %d Applications", t.MaxApplications))
+	}
+	if t.MaxApplicationsPerNamespace > 0 && usage.Namespaces[config.AppDestinationNamespace]+1 > t.MaxApplicationsPerNamespace {
+		exceeded = append(exceeded, fmt.Sprintf("maximum of %d Applications in namespace %#q", t.MaxApplicationsPerNamespace, config.AppDestinationNamespace))
+	}
+	if _, ok := usage.Clusters[cluster]; !ok && t.MaxClusters > 0 && len(usage.Clusters)+1 > t.MaxClusters {
+		exceeded = append(exceeded, fmt.Sprintf("maximum of %d clusters", t.MaxClusters))
+	}
+
+	if len(exceeded) > 0 {
+		return microerror.Maskf(quotaExceededError, "tenant %#q: %s", tenant, strings.Join(exceeded, "; "))
+	}
+
+	return nil
+}
+
+func computeUsage(tenant string, apps []unstructured.Unstructured, exclude string) Usage {
+	usage := Usage{
+		Namespaces: map[string]int{},
+		Clusters:   map[string]int{},
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{TenantLabel: tenant})
+	for _, app := range apps {
+		if !selector.Matches(labels.Set(app.GetLabels())) || app.GetName() == exclude {
+			continue
+		}
+
+		namespace, _, _ := unstructured.NestedString(app.Object, "spec", "destination", "namespace")
+		cluster, _, _ := unstructured.NestedString(app.Object, "spec", "destination", "name")
+		if cluster == "" {
+			cluster, _, _ = unstructured.NestedString(app.Object, "spec", "destination", "server")
+		}
+
+		usage.Applications++
+		usage.Namespaces[namespace]++
+		usage.Clusters[cluster]++
+	}
+
+	return usage
+}
@@ -0,0 +1,183 @@
+// Package tenancy validates ApplicationConfigs against a tenant policy so
+// teams can only deploy the allowed apps to the allowed destinations.
+package tenancy
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/giantswarm/argoapp/pkg/argoapp"
+)
+
+// inClusterServer is the destination server of Applications without
+// destination server or name set.
+const inClusterServer = "https://kubernetes.default.svc"
+
+// Policy lists what each tenant is allowed to deploy. Tenants not listed are
+// not allowed to deploy anything.
+type Policy struct {
+	Tenants []Tenant `json:"tenants"`
+}
+
+// Tenant is the policy of a single team. All the fields are lists of glob
+// patterns as understood by path.Match, except "*" matches anything including
+// cluster server URLs. Empty lists allow nothing.
+type Tenant struct {
+	Name string `json:"name"`
+	// Namespaces are the allowed destination namespaces.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Catalogs are the allowed app catalogs.
+	Catalogs []string `json:"catalogs,omitempty"`
+	// Clusters are the allowed destination cluster names or servers. The
+	// in-cluster destination is https://kubernetes.default.svc.
+	Clusters []string `json:"clusters,omitempty"`
+
+	// Quotas are checked with CheckQuota. Zero means unlimited.
+
+	// MaxApplications is the maximum number of Applications of the tenant.
+	MaxApplications int `json:"maxApplications,omitempty"`
+	// MaxApplicationsPerNamespace is the maximum number of Applications of
+	// the tenant per destination namespace.
+	MaxApplicationsPerNamespace int `json:"maxApplicationsPerNamespace,omitempty"`
+	// MaxClusters is the maximum number of clusters targeted by the
+	// Applications of the tenant.
+	MaxClusters int `json:"maxClusters,omitempty"`
+}
+
+// LoadPolicy reads a YAML or JSON encoded Policy, e.g. from a file.
+func LoadPolicy(r io.Reader) (Policy, error) {
+	var p Policy
+	err := yaml.NewYAMLOrJSONDecoder(r, 4096).Decode(&p)
+	if err != nil {
+		return Policy{}, microerror.Mask(err)
+	}
+
+	err = p.validate()
+	if err != nil {
+		return Policy{}, microerror.Mask(err)
+	}
+
+	return p, nil
+}
+
+// PolicyFromUnstructured returns the Policy from the spec of a custom
+// resource, e.g. fetched with a dynamic client.
+func PolicyFromUnstructured(obj *unstructured.Unstructured) (Policy, error) {
+	spec, ok, err := unstructured.NestedMap(obj.Object, "spec")
+	if err != nil {
+		return Policy{}, microerror.Mask(err)
+	} else if !ok {
+		return Policy{}, microerror.Maskf(invalidConfigError, "%s %#q has no spec", obj.GetKind(), obj.GetName())
+	}
+
+	var p Policy
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(spec, &p)
+	if err != nil {
+		return Policy{}, microerror.Mask(err)
+	}
+
+	err = p.validate()
+	if err != nil {
+		return Policy{}, microerror.Mask(err)
+	}
+
+	return p, nil
+}
+
+// Validate returns an error matched by IsPolicyViolation listing all the
+// violations when the tenant is not allowed to deploy the Application
+// described by config.
+func (p Policy) Validate(tenant string, config argoapp.ApplicationConfig) error {
+	t, ok := p.tenant(tenant)
+	if !ok {
+		return microerror.Maskf(policyViolationError, "tenant %#q is not allowed to deploy any Application", tenant)
+	}
+
+	cluster := configCluster(config)
+
+	var violations []string
+	if !matchAny(t.Namespaces, config.AppDestinationNamespace) {
+		violations = append(violations, fmt.Sprintf("destination namespace %#q is not allowed", config.AppDestinationNamespace))
+	}
+	if !matchAny(t.Catalogs, config.AppCatalog) {
+		violations = append(violations, fmt.Sprintf("catalog %#q is not allowed", config.AppCatalog))
+	}
+	if !matchAny(t.Clusters, cluster) {
+		violations = append(violations, fmt.Sprintf("cluster %#q is not allowed", cluster))
+	}
+
+	if len(violations) > 0 {
+		return microerror.Maskf(policyViolationError, "tenant %#q: %s", tenant, strings.Join(violations, "; "))
+	}
+
+	return nil
+}
+
+func (p Policy) tenant(name string) (Tenant, bool) {
+	for _, t := range p.Tenants {
+		if t.Name == name {
+			return t, true
+		}
+	}
+
+	return Tenant{}, false
+}
+
+func (p Policy) validate() error {
+	seen := map[string]bool{}
+	for i, t := range p.Tenants {
+		if t.Name == "" {
+			return microerror.Maskf(invalidConfigError, "%T.Tenants[%d].Name must not be empty", p, i)
+		}
+		if seen[t.Name] {
+			return microerror.Maskf(invalidConfigError, "%T.Tenants has duplicate tenant %#q", p, t.Name)
+		}
+		seen[t.Name] = true
+
+		if t.MaxApplications < 0 || t.MaxApplicationsPerNamespace < 0 || t.MaxClusters < 0 {
+			return microerror.Maskf(invalidConfigError, "tenant %#q quotas must not be negative", t.Name)
+		}
+
+		for _, patterns := range [][]string{t.Namespaces, t.Catalogs, t.Clusters} {
+			for _, pattern := range patterns {
+				_, err := path.Match(pattern, "")
+				if err != nil {
+					return microerror.Maskf(invalidConfigError, "tenant %#q pattern %#q is invalid: %s", t.Name, pattern, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func configCluster(config argoapp.ApplicationConfig) string {
+	if config.AppDestinationName != "" {
+		return config.AppDestinationName
+	}
+	if config.AppDestinationServer != "" {
+		return config.AppDestinationServer
+	}
+
+	return inClusterServer
+}
+
+func matchAny(patterns []string, s string) bool {
+	for _, p := range patterns {
+		if p == "*" {
+			return true
+		}
+		if ok, _ := path.Match(p, s); ok {
+			return true
+		}
+	}
+
+	return false
+}